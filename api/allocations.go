@@ -106,6 +106,8 @@ type Allocation struct {
 	ClientStatus       string
 	ClientDescription  string
 	TaskStates         map[string]*TaskState
+	DeploymentID       string
+	DeploymentStatus   *AllocDeploymentStatus
 	PreviousAllocation string
 	CreateIndex        uint64
 	ModifyIndex        uint64
@@ -142,11 +144,19 @@ type AllocationListStub struct {
 	ClientStatus       string
 	ClientDescription  string
 	TaskStates         map[string]*TaskState
+	DeploymentStatus   *AllocDeploymentStatus
 	CreateIndex        uint64
 	ModifyIndex        uint64
 	CreateTime         int64
 }
 
+// AllocDeploymentStatus captures the status of the allocation as part of the
+// deployment. This can include things like if the allocation has been marked as
+// healthy.
+type AllocDeploymentStatus struct {
+	Healthy *bool
+}
+
 // AllocIndexSort reverse sorts allocs by CreateIndex.
 type AllocIndexSort []*AllocationListStub
 
@@ -0,0 +1,329 @@
+package agent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+func TestHTTP_DeploymentList(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Directly manipulate the state
+		state := s.Agent.server.State()
+		d1 := mock.Deployment()
+		d2 := mock.Deployment()
+		if err := state.UpsertDeployment(999, d1, false); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if err := state.UpsertDeployment(1000, d2, false); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Make the HTTP request
+		req, err := http.NewRequest("GET", "/v1/deployments", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.DeploymentsRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check for the index
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+		if respW.HeaderMap.Get("X-Nomad-KnownLeader") != "true" {
+			t.Fatalf("missing known leader")
+		}
+		if respW.HeaderMap.Get("X-Nomad-LastContact") == "" {
+			t.Fatalf("missing last contact")
+		}
+
+		// Check the deployments
+		deploys := obj.([]*structs.Deployment)
+		if len(deploys) != 2 {
+			t.Fatalf("bad: %#v", deploys)
+		}
+	})
+}
+
+func TestHTTP_DeploymentPrefixList(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Directly manipulate the state
+		state := s.Agent.server.State()
+		d1 := mock.Deployment()
+		d1.ID = "aaabbbbb-e8f7-fd38-c855-ab94ceb89706"
+		d2 := mock.Deployment()
+		d2.ID = "aaabbbbb-e8f7-fd38-c855-ab94ceb89707"
+		if err := state.UpsertDeployment(999, d1, false); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if err := state.UpsertDeployment(1000, d2, false); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Make the HTTP request
+		req, err := http.NewRequest("GET", "/v1/deployments?prefix=aaab", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.DeploymentsRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check for the index
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+
+		// Check the deployments
+		deploys := obj.([]*structs.Deployment)
+		if len(deploys) != 2 {
+			t.Fatalf("bad: %#v", deploys)
+		}
+	})
+}
+
+func TestHTTP_DeploymentAllocations(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Directly manipulate the state
+		state := s.Agent.server.State()
+		j := mock.Job()
+		d := mock.Deployment()
+		d.JobID = j.ID
+		a1 := mock.Alloc()
+		a1.JobID = j.ID
+		a1.DeploymentID = d.ID
+		a2 := mock.Alloc()
+		a2.JobID = j.ID
+		a2.DeploymentID = d.ID
+		state.UpsertJobSummary(997, mock.JobSummary(j.ID))
+		if err := state.UpsertJob(998, j); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if err := state.UpsertDeployment(999, d, false); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if err := state.UpsertAllocs(1000, []*structs.Allocation{a1, a2}); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Make the HTTP request
+		req, err := http.NewRequest("GET", "/v1/deployment/allocations/"+d.ID, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.DeploymentSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check for the index
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+
+		// Check the allocations
+		allocs := obj.([]*structs.AllocListStub)
+		if len(allocs) != 2 {
+			t.Fatalf("bad: %#v", allocs)
+		}
+	})
+}
+
+func TestHTTP_DeploymentQuery(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Directly manipulate the state
+		state := s.Agent.server.State()
+		d := mock.Deployment()
+		if err := state.UpsertDeployment(1000, d, false); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Make the HTTP request
+		req, err := http.NewRequest("GET", "/v1/deployment/"+d.ID, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.DeploymentSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check for the index
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+		if respW.HeaderMap.Get("X-Nomad-KnownLeader") != "true" {
+			t.Fatalf("missing known leader")
+		}
+		if respW.HeaderMap.Get("X-Nomad-LastContact") == "" {
+			t.Fatalf("missing last contact")
+		}
+
+		// Check the deployment
+		out := obj.(*structs.Deployment)
+		if out.ID != d.ID {
+			t.Fatalf("bad: %#v", out)
+		}
+
+		// Query a missing deployment
+		req, err = http.NewRequest("GET", "/v1/deployment/"+structs.GenerateUUID(), nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if _, err := s.Server.DeploymentSpecificRequest(httptest.NewRecorder(), req); err == nil {
+			t.Fatalf("expected not found error")
+		}
+	})
+}
+
+func TestHTTP_DeploymentPause(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Directly manipulate the state
+		state := s.Agent.server.State()
+		j := mock.Job()
+		d := mock.Deployment()
+		d.JobID = j.ID
+		if err := state.UpsertJob(999, j); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if err := state.UpsertDeployment(1000, d, false); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Create the pause request
+		args := structs.DeploymentPauseRequest{
+			DeploymentID: d.ID,
+			Pause:        false,
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}
+		buf := encodeReq(args)
+
+		// Make the HTTP request
+		req, err := http.NewRequest("PUT", "/v1/deployment/pause/"+d.ID, buf)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.DeploymentSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check the response
+		resp := obj.(structs.DeploymentUpdateResponse)
+		if resp.EvalID == "" || resp.EvalCreateIndex == 0 || resp.DeploymentModifyIndex == 0 {
+			t.Fatalf("bad: %#v", resp)
+		}
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+	})
+}
+
+func TestHTTP_DeploymentPromote(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Directly manipulate the state
+		state := s.Agent.server.State()
+		j := mock.Job()
+		d := mock.Deployment()
+		d.JobID = j.ID
+		d.TaskGroups["web"].RequiresPromotion = true
+		d.TaskGroups["web"].DesiredCanaries = 1
+		d.TaskGroups["web"].HealthyAllocs = 1
+		if err := state.UpsertJob(999, j); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if err := state.UpsertDeployment(1000, d, false); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Create the promote request
+		args := structs.DeploymentPromoteRequest{
+			DeploymentID: d.ID,
+			All:          true,
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}
+		buf := encodeReq(args)
+
+		// Make the HTTP request
+		req, err := http.NewRequest("PUT", "/v1/deployment/promote/"+d.ID, buf)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.DeploymentSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check the response
+		resp := obj.(structs.DeploymentUpdateResponse)
+		if resp.EvalID == "" || resp.EvalCreateIndex == 0 || resp.DeploymentModifyIndex == 0 {
+			t.Fatalf("bad: %#v", resp)
+		}
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+	})
+}
+
+func TestHTTP_DeploymentFail(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Directly manipulate the state
+		state := s.Agent.server.State()
+		j := mock.Job()
+		d := mock.Deployment()
+		d.JobID = j.ID
+		if err := state.UpsertJob(999, j); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if err := state.UpsertDeployment(1000, d, false); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Make the HTTP request
+		req, err := http.NewRequest("PUT", "/v1/deployment/fail/"+d.ID, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.DeploymentSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check the response
+		resp := obj.(structs.DeploymentUpdateResponse)
+		if resp.EvalID == "" || resp.EvalCreateIndex == 0 || resp.DeploymentModifyIndex == 0 {
+			t.Fatalf("bad: %#v", resp)
+		}
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+	})
+}
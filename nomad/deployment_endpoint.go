@@ -0,0 +1,328 @@
+package nomad
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/nomad/nomad/state"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// Deployment endpoint is used for manipulating deployments
+type Deployment struct {
+	srv *Server
+}
+
+// GetDeployment is used to request information about a specific deployment
+func (d *Deployment) GetDeployment(args *structs.DeploymentSpecificRequest,
+	reply *structs.SingleDeploymentResponse) error {
+	if done, err := d.srv.forward("Deployment.GetDeployment", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "deployment", "get_deployment"}, time.Now())
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		run: func(ws memdb.WatchSet, state *state.StateStore) error {
+			// Verify the arguments
+			if args.DeploymentID == "" {
+				return fmt.Errorf("missing deployment ID")
+			}
+
+			// Look for the deployment
+			out, err := state.DeploymentByID(ws, args.DeploymentID)
+			if err != nil {
+				return err
+			}
+
+			// Setup the output
+			reply.Deployment = out
+			if out != nil {
+				reply.Index = out.ModifyIndex
+			} else {
+				// Use the last index that affected the deployments table
+				index, err := state.Index("deployment")
+				if err != nil {
+					return err
+				}
+				reply.Index = index
+			}
+
+			// Set the query response
+			d.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return d.srv.blockingRPC(&opts)
+}
+
+// Fail is used to force fail a deployment
+func (d *Deployment) Fail(args *structs.DeploymentFailRequest, reply *structs.DeploymentUpdateResponse) error {
+	if done, err := d.srv.forward("Deployment.Fail", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "deployment", "fail"}, time.Now())
+
+	// Validate the arguments
+	if args.DeploymentID == "" {
+		return fmt.Errorf("missing deployment ID")
+	}
+
+	// Lookup the deployment and its job
+	deploy, job, err := d.activeDeployment(args.DeploymentID)
+	if err != nil {
+		return err
+	}
+
+	// Fail the deployment and create an evaluation so the scheduler reacts
+	req := &structs.DeploymentStatusUpdateRequest{
+		DeploymentUpdate: &structs.DeploymentStatusUpdate{
+			DeploymentID:      deploy.ID,
+			Status:            structs.DeploymentStatusFailed,
+			StatusDescription: structs.DeploymentStatusDescriptionFailedByUser,
+		},
+		WriteRequest: args.WriteRequest,
+	}
+	if job != nil {
+		req.Eval = newDeploymentEval(job)
+	}
+
+	// Commit the update via Raft
+	resp, index, err := d.srv.raftApply(structs.DeploymentStatusUpdateRequestType, req)
+	if err != nil {
+		d.srv.logger.Printf("[ERR] nomad.deployment: Fail failed: %v", err)
+		return err
+	} else if respErr, ok := resp.(error); ok {
+		return respErr
+	}
+
+	// Setup the reply
+	d.setUpdateReply(reply, req.Eval, index)
+	return nil
+}
+
+// Pause is used to pause a deployment
+func (d *Deployment) Pause(args *structs.DeploymentPauseRequest, reply *structs.DeploymentUpdateResponse) error {
+	if done, err := d.srv.forward("Deployment.Pause", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "deployment", "pause"}, time.Now())
+
+	// Validate the arguments
+	if args.DeploymentID == "" {
+		return fmt.Errorf("missing deployment ID")
+	}
+
+	// Lookup the deployment and its job
+	deploy, job, err := d.activeDeployment(args.DeploymentID)
+	if err != nil {
+		return err
+	}
+
+	req := &structs.DeploymentStatusUpdateRequest{
+		DeploymentUpdate: &structs.DeploymentStatusUpdate{
+			DeploymentID: deploy.ID,
+		},
+		WriteRequest: args.WriteRequest,
+	}
+	if args.Pause {
+		req.DeploymentUpdate.Status = structs.DeploymentStatusPaused
+		req.DeploymentUpdate.StatusDescription = structs.DeploymentStatusDescriptionPaused
+	} else {
+		req.DeploymentUpdate.Status = structs.DeploymentStatusRunning
+		req.DeploymentUpdate.StatusDescription = structs.DeploymentStatusDescriptionRunning
+
+		// Create an evaluation so the scheduler continues the deployment
+		if job != nil {
+			req.Eval = newDeploymentEval(job)
+		}
+	}
+
+	// Commit the update via Raft
+	resp, index, err := d.srv.raftApply(structs.DeploymentStatusUpdateRequestType, req)
+	if err != nil {
+		d.srv.logger.Printf("[ERR] nomad.deployment: Pause failed: %v", err)
+		return err
+	} else if respErr, ok := resp.(error); ok {
+		return respErr
+	}
+
+	// Setup the reply
+	d.setUpdateReply(reply, req.Eval, index)
+	return nil
+}
+
+// Promote is used to promote canaries in a deployment
+func (d *Deployment) Promote(args *structs.DeploymentPromoteRequest, reply *structs.DeploymentUpdateResponse) error {
+	if done, err := d.srv.forward("Deployment.Promote", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "deployment", "promote"}, time.Now())
+
+	// Validate the arguments
+	if args.DeploymentID == "" {
+		return fmt.Errorf("missing deployment ID")
+	}
+	if !args.All && len(args.Groups) == 0 {
+		return fmt.Errorf("must specify the task groups to promote or promote all")
+	}
+
+	// Lookup the deployment and its job
+	deploy, job, err := d.activeDeployment(args.DeploymentID)
+	if err != nil {
+		return err
+	}
+
+	// Promote the canaries and create an evaluation so the scheduler replaces
+	// the remaining allocations
+	req := &structs.ApplyDeploymentPromoteRequest{
+		DeploymentPromoteRequest: *args,
+	}
+	req.DeploymentID = deploy.ID
+	if job != nil {
+		req.Eval = newDeploymentEval(job)
+	}
+
+	// Commit the promotion via Raft
+	resp, index, err := d.srv.raftApply(structs.DeploymentPromoteRequestType, req)
+	if err != nil {
+		d.srv.logger.Printf("[ERR] nomad.deployment: Promote failed: %v", err)
+		return err
+	} else if respErr, ok := resp.(error); ok {
+		return respErr
+	}
+
+	// Setup the reply
+	d.setUpdateReply(reply, req.Eval, index)
+	return nil
+}
+
+// List returns the list of deployments in the system
+func (d *Deployment) List(args *structs.DeploymentListRequest, reply *structs.DeploymentListResponse) error {
+	if done, err := d.srv.forward("Deployment.List", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "deployment", "list"}, time.Now())
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		run: func(ws memdb.WatchSet, state *state.StateStore) error {
+			// Capture all the deployments
+			var err error
+			var iter memdb.ResultIterator
+			if prefix := args.QueryOptions.Prefix; prefix != "" {
+				iter, err = state.DeploymentsByIDPrefix(ws, prefix)
+			} else {
+				iter, err = state.Deployments(ws)
+			}
+			if err != nil {
+				return err
+			}
+
+			var deploys []*structs.Deployment
+			for {
+				raw := iter.Next()
+				if raw == nil {
+					break
+				}
+				deploy := raw.(*structs.Deployment)
+				deploys = append(deploys, deploy)
+			}
+			reply.Deployments = deploys
+
+			// Use the last index that affected the deployment table
+			index, err := state.Index("deployment")
+			if err != nil {
+				return err
+			}
+			reply.Index = index
+
+			// Set the query response
+			d.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return d.srv.blockingRPC(&opts)
+}
+
+// Allocations returns the list of allocations that are a part of the deployment
+func (d *Deployment) Allocations(args *structs.DeploymentSpecificRequest, reply *structs.AllocListResponse) error {
+	if done, err := d.srv.forward("Deployment.Allocations", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "deployment", "allocations"}, time.Now())
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		run: func(ws memdb.WatchSet, state *state.StateStore) error {
+			// Capture all the allocations
+			allocs, err := state.AllocsByDeployment(ws, args.DeploymentID)
+			if err != nil {
+				return err
+			}
+
+			stubs := make([]*structs.AllocListStub, 0, len(allocs))
+			for _, alloc := range allocs {
+				stubs = append(stubs, alloc.Stub())
+			}
+			reply.Allocations = stubs
+
+			// Use the last index that affected the allocs table
+			index, err := state.Index("allocs")
+			if err != nil {
+				return err
+			}
+			reply.Index = index
+
+			// Set the query response
+			d.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return d.srv.blockingRPC(&opts)
+}
+
+// activeDeployment returns the active deployment with the given ID and the
+// job it is deploying. An error is returned if the deployment doesn't exist or
+// is terminal.
+func (d *Deployment) activeDeployment(deploymentID string) (*structs.Deployment, *structs.Job, error) {
+	snap, err := d.srv.fsm.State().Snapshot()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ws := memdb.NewWatchSet()
+	deploy, err := snap.DeploymentByID(ws, deploymentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if deploy == nil {
+		return nil, nil, fmt.Errorf("deployment not found")
+	}
+	if !deploy.Active() {
+		return nil, nil, fmt.Errorf("can't update terminal deployment")
+	}
+
+	job, err := snap.JobByID(ws, deploy.JobID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return deploy, job, nil
+}
+
+// setUpdateReply populates the reply of a deployment update committed at the
+// given index.
+func (d *Deployment) setUpdateReply(reply *structs.DeploymentUpdateResponse, eval *structs.Evaluation, index uint64) {
+	if eval != nil {
+		reply.EvalID = eval.ID
+		reply.EvalCreateIndex = index
+	}
+	reply.DeploymentModifyIndex = index
+	reply.Index = index
+}
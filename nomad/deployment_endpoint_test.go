@@ -0,0 +1,404 @@
+package nomad
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	memdb "github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/net-rpc-msgpackrpc"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+)
+
+func TestDeploymentEndpoint_GetDeployment(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the deployment
+	d := mock.Deployment()
+	state := s1.fsm.State()
+	if err := state.UpsertDeployment(1000, d, false); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Lookup the deployment
+	get := &structs.DeploymentSpecificRequest{
+		DeploymentID: d.ID,
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var resp structs.SingleDeploymentResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.GetDeployment", get, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index != 1000 {
+		t.Fatalf("Bad index: %d %d", resp.Index, 1000)
+	}
+	if !reflect.DeepEqual(d, resp.Deployment) {
+		t.Fatalf("bad: %#v %#v", d, resp.Deployment)
+	}
+
+	// Lookup non-existing deployment
+	get.DeploymentID = structs.GenerateUUID()
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.GetDeployment", get, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index != 1000 {
+		t.Fatalf("Bad index: %d %d", resp.Index, 1000)
+	}
+	if resp.Deployment != nil {
+		t.Fatalf("unexpected deployment")
+	}
+}
+
+func TestDeploymentEndpoint_GetDeployment_Blocking(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	state := s1.fsm.State()
+
+	// Create the deployments
+	d1 := mock.Deployment()
+	d2 := mock.Deployment()
+
+	// Upsert an unrelated deployment first
+	time.AfterFunc(100*time.Millisecond, func() {
+		if err := state.UpsertDeployment(100, d1, false); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	})
+
+	// Upsert the deployment we are watching later
+	time.AfterFunc(200*time.Millisecond, func() {
+		if err := state.UpsertDeployment(200, d2, false); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	})
+
+	// Lookup the deployment
+	get := &structs.DeploymentSpecificRequest{
+		DeploymentID: d2.ID,
+		QueryOptions: structs.QueryOptions{
+			Region:        "global",
+			MinQueryIndex: 150,
+		},
+	}
+	var resp structs.SingleDeploymentResponse
+	start := time.Now()
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.GetDeployment", get, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Fatalf("should block (returned in %s) %#v", elapsed, resp)
+	}
+	if resp.Index != 200 {
+		t.Fatalf("Bad index: %d %d", resp.Index, 200)
+	}
+	if resp.Deployment == nil || resp.Deployment.ID != d2.ID {
+		t.Fatalf("bad: %#v", resp.Deployment)
+	}
+}
+
+func TestDeploymentEndpoint_Fail(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the job and a deployment for it
+	j := mock.Job()
+	d := mock.Deployment()
+	d.JobID = j.ID
+	state := s1.fsm.State()
+
+	if err := state.UpsertJob(999, j); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.UpsertDeployment(1000, d, false); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Mark the deployment as failed
+	req := &structs.DeploymentFailRequest{
+		DeploymentID: d.ID,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+
+	var resp structs.DeploymentUpdateResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.Fail", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index == 0 || resp.EvalID == "" || resp.DeploymentModifyIndex != resp.Index {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// Lookup the evaluation
+	ws := memdb.NewWatchSet()
+	eval, err := state.EvalByID(ws, resp.EvalID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if eval == nil || eval.CreateIndex != resp.EvalCreateIndex {
+		t.Fatalf("bad: %#v", eval)
+	}
+	if eval.JobID != j.ID || eval.TriggeredBy != structs.EvalTriggerDeploymentWatcher {
+		t.Fatalf("bad: %#v", eval)
+	}
+
+	// Lookup the deployment
+	dout, err := state.DeploymentByID(ws, d.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if dout.Status != structs.DeploymentStatusFailed ||
+		dout.StatusDescription != structs.DeploymentStatusDescriptionFailedByUser {
+		t.Fatalf("bad: %#v", dout)
+	}
+	if dout.ModifyIndex != resp.DeploymentModifyIndex {
+		t.Fatalf("bad: %#v", dout)
+	}
+
+	// Failing a terminal deployment errors
+	err = msgpackrpc.CallWithCodec(codec, "Deployment.Fail", req, &resp)
+	if err == nil || !strings.Contains(err.Error(), "terminal") {
+		t.Fatalf("expected terminal error: %v", err)
+	}
+}
+
+func TestDeploymentEndpoint_Pause(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the job and a deployment for it
+	j := mock.Job()
+	d := mock.Deployment()
+	d.JobID = j.ID
+	state := s1.fsm.State()
+
+	if err := state.UpsertJob(999, j); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.UpsertDeployment(1000, d, false); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Pause the deployment
+	req := &structs.DeploymentPauseRequest{
+		DeploymentID: d.ID,
+		Pause:        true,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+
+	var resp structs.DeploymentUpdateResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.Pause", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index == 0 || resp.EvalID != "" {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// Lookup the deployment
+	ws := memdb.NewWatchSet()
+	dout, err := state.DeploymentByID(ws, d.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if dout.Status != structs.DeploymentStatusPaused ||
+		dout.StatusDescription != structs.DeploymentStatusDescriptionPaused {
+		t.Fatalf("bad: %#v", dout)
+	}
+
+	// Resume the deployment
+	req.Pause = false
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.Pause", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index == 0 || resp.EvalID == "" {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// Lookup the evaluation
+	eval, err := state.EvalByID(ws, resp.EvalID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if eval == nil || eval.JobID != j.ID {
+		t.Fatalf("bad: %#v", eval)
+	}
+
+	// Lookup the deployment
+	dout, err = state.DeploymentByID(ws, d.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if dout.Status != structs.DeploymentStatusRunning ||
+		dout.StatusDescription != structs.DeploymentStatusDescriptionRunning {
+		t.Fatalf("bad: %#v", dout)
+	}
+}
+
+func TestDeploymentEndpoint_Promote(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the job and a deployment requiring promotion
+	j := mock.Job()
+	d := mock.Deployment()
+	d.JobID = j.ID
+	d.TaskGroups["web"].RequiresPromotion = true
+	d.TaskGroups["web"].DesiredCanaries = 2
+	state := s1.fsm.State()
+
+	if err := state.UpsertJob(999, j); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.UpsertDeployment(1000, d, false); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Promoting before the canaries are healthy fails
+	req := &structs.DeploymentPromoteRequest{
+		DeploymentID: d.ID,
+		All:          true,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+
+	var resp structs.DeploymentUpdateResponse
+	err := msgpackrpc.CallWithCodec(codec, "Deployment.Promote", req, &resp)
+	if err == nil || !strings.Contains(err.Error(), "healthy allocations") {
+		t.Fatalf("expected unhealthy error: %v", err)
+	}
+
+	// Mark the canaries as healthy
+	d2 := d.Copy()
+	d2.TaskGroups["web"].HealthyAllocs = 2
+	if err := state.UpsertDeployment(1001, d2, false); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Promote the deployment
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.Promote", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index == 0 || resp.EvalID == "" {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// Lookup the evaluation
+	ws := memdb.NewWatchSet()
+	eval, err := state.EvalByID(ws, resp.EvalID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if eval == nil || eval.JobID != j.ID {
+		t.Fatalf("bad: %#v", eval)
+	}
+
+	// Lookup the deployment
+	dout, err := state.DeploymentByID(ws, d.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !dout.TaskGroups["web"].Promoted {
+		t.Fatalf("bad: %#v", dout.TaskGroups["web"])
+	}
+}
+
+func TestDeploymentEndpoint_List(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the deployment
+	d := mock.Deployment()
+	d.ID = "aaaaaaaa-3350-4b4b-d185-0e1992ed43e9"
+	state := s1.fsm.State()
+	if err := state.UpsertDeployment(1000, d, false); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Lookup the deployments
+	get := &structs.DeploymentListRequest{
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var resp structs.DeploymentListResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.List", get, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index != 1000 {
+		t.Fatalf("Bad index: %d %d", resp.Index, 1000)
+	}
+	if len(resp.Deployments) != 1 || resp.Deployments[0].ID != d.ID {
+		t.Fatalf("bad: %#v", resp.Deployments)
+	}
+
+	// Lookup the deployments by prefix
+	get.Prefix = "aaaa"
+	var resp2 structs.DeploymentListResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.List", get, &resp2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(resp2.Deployments) != 1 || resp2.Deployments[0].ID != d.ID {
+		t.Fatalf("bad: %#v", resp2.Deployments)
+	}
+
+	// Lookup with a prefix that doesn't match
+	get.Prefix = "bbbb"
+	var resp3 structs.DeploymentListResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.List", get, &resp3); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(resp3.Deployments) != 0 {
+		t.Fatalf("bad: %#v", resp3.Deployments)
+	}
+}
+
+func TestDeploymentEndpoint_Allocations(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the deployment and allocations belonging to it
+	d := mock.Deployment()
+	a := mock.Alloc()
+	a.DeploymentID = d.ID
+	a2 := mock.Alloc()
+	state := s1.fsm.State()
+
+	if err := state.UpsertDeployment(999, d, false); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	state.UpsertJobSummary(998, mock.JobSummary(a.JobID))
+	state.UpsertJobSummary(998, mock.JobSummary(a2.JobID))
+	if err := state.UpsertAllocs(1000, []*structs.Allocation{a, a2}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Lookup the allocations
+	get := &structs.DeploymentSpecificRequest{
+		DeploymentID: d.ID,
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var resp structs.AllocListResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.Allocations", get, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index != 1000 {
+		t.Fatalf("Bad index: %d %d", resp.Index, 1000)
+	}
+	if len(resp.Allocations) != 1 || resp.Allocations[0].ID != a.ID {
+		t.Fatalf("bad: %#v", resp.Allocations)
+	}
+}
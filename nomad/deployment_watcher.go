@@ -0,0 +1,419 @@
+package nomad
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	memdb "github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/nomad/nomad/state"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+const (
+	// deploymentWatcherRetryInterval is the interval at which the deployment
+	// watcher retries a failed state query or Raft transaction.
+	deploymentWatcherRetryInterval = 5 * time.Second
+)
+
+var (
+	// errDeploymentWatcherStopped is returned by blocking queries when the
+	// watcher they belong to has been stopped.
+	errDeploymentWatcherStopped = errors.New("deployment watcher stopped")
+)
+
+// DeploymentWatcherBackend is the interface the deployment watcher uses to read
+// the current state and to commit the changes it makes through Raft.
+type DeploymentWatcherBackend interface {
+	// State returns the current state store.
+	State() *state.StateStore
+
+	// UpsertEvals is used to create a set of evaluations.
+	UpsertEvals(evals []*structs.Evaluation) (uint64, error)
+
+	// UpdateDeploymentStatus is used to make a deployment status update and
+	// potentially create an evaluation.
+	UpdateDeploymentStatus(req *structs.DeploymentStatusUpdateRequest) (uint64, error)
+}
+
+// UpsertEvals creates the passed evaluations by committing them to the raft
+// log. It returns the index at which they were committed.
+func (s *Server) UpsertEvals(evals []*structs.Evaluation) (uint64, error) {
+	req := structs.EvalUpdateRequest{
+		Evals: evals,
+	}
+	_, index, err := s.raftApply(structs.EvalUpdateRequestType, &req)
+	return index, err
+}
+
+// UpdateDeploymentStatus commits the passed deployment status update and its
+// optional evaluation to the raft log. It returns the index at which the update
+// was committed.
+func (s *Server) UpdateDeploymentStatus(req *structs.DeploymentStatusUpdateRequest) (uint64, error) {
+	resp, index, err := s.raftApply(structs.DeploymentStatusUpdateRequestType, req)
+	if err != nil {
+		return 0, err
+	} else if respErr, ok := resp.(error); ok {
+		return 0, respErr
+	}
+	return index, nil
+}
+
+// DeploymentWatcher is used to watch deployments and drive them to completion.
+// It tracks every active deployment, creating evaluations as the deployment's
+// allocations become healthy so the scheduler can continue the rollout, and
+// marks deployments as successful or failed. It should only be enabled on the
+// active leader.
+type DeploymentWatcher struct {
+	enabled bool
+	backend DeploymentWatcherBackend
+	logger  *log.Logger
+
+	// watchers is the set of active deployments being watched, keyed by
+	// deployment ID.
+	watchers map[string]*deploymentWatcher
+
+	// stopCh is used to stop the goroutine watching the deployments table.
+	stopCh chan struct{}
+
+	l sync.RWMutex
+}
+
+// NewDeploymentWatcher returns a deployment watcher that uses the passed
+// backend to read state and commit deployment updates.
+func NewDeploymentWatcher(logger *log.Logger, backend DeploymentWatcherBackend) *DeploymentWatcher {
+	return &DeploymentWatcher{
+		backend:  backend,
+		logger:   logger,
+		watchers: make(map[string]*deploymentWatcher),
+	}
+}
+
+// SetEnabled is used to control if the deployment watcher is enabled. The
+// watcher should only be enabled on the active leader.
+func (w *DeploymentWatcher) SetEnabled(enabled bool) {
+	w.l.Lock()
+	defer w.l.Unlock()
+
+	if w.enabled == enabled {
+		return
+	}
+	w.enabled = enabled
+
+	if enabled {
+		w.stopCh = make(chan struct{})
+		go w.watchDeployments(w.stopCh)
+		return
+	}
+
+	// Stop watching the deployments table and every deployment
+	close(w.stopCh)
+	for id, watcher := range w.watchers {
+		watcher.stop()
+		delete(w.watchers, id)
+	}
+}
+
+// Enabled returns whether the deployment watcher is enabled.
+func (w *DeploymentWatcher) Enabled() bool {
+	w.l.RLock()
+	defer w.l.RUnlock()
+	return w.enabled
+}
+
+// watchDeployments watches the deployments table and starts or stops watching
+// individual deployments as they become active or terminal.
+func (w *DeploymentWatcher) watchDeployments(stopCh chan struct{}) {
+	index := uint64(0)
+	for {
+		deployments, newIndex, err := w.getDeploys(stopCh, index)
+		if err == errDeploymentWatcherStopped {
+			return
+		} else if err != nil {
+			w.logger.Printf("[ERR] nomad.deployment_watcher: failed to retrieve deployments: %v", err)
+			select {
+			case <-stopCh:
+				return
+			case <-time.After(deploymentWatcherRetryInterval):
+			}
+			continue
+		}
+		index = newIndex
+
+		w.l.Lock()
+		if !w.enabled {
+			w.l.Unlock()
+			return
+		}
+		for _, d := range deployments {
+			if d.Active() {
+				w.add(d)
+			} else {
+				w.remove(d.ID)
+			}
+		}
+		w.l.Unlock()
+	}
+}
+
+// getDeploys blocks until the deployments table has an index greater than the
+// passed index and returns the deployments and the table's index.
+func (w *DeploymentWatcher) getDeploys(stopCh chan struct{}, minIndex uint64) ([]*structs.Deployment, uint64, error) {
+	var deployments []*structs.Deployment
+	index, err := blockingStateQuery(w.backend, stopCh, minIndex, func(ws memdb.WatchSet, state *state.StateStore) (uint64, error) {
+		iter, err := state.Deployments(ws)
+		if err != nil {
+			return 0, err
+		}
+
+		deployments = nil
+		for raw := iter.Next(); raw != nil; raw = iter.Next() {
+			deployments = append(deployments, raw.(*structs.Deployment))
+		}
+
+		return state.Index("deployment")
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return deployments, index, nil
+}
+
+// add starts watching the passed deployment if it isn't already being watched.
+// The lock must be held by the caller.
+func (w *DeploymentWatcher) add(d *structs.Deployment) {
+	if _, ok := w.watchers[d.ID]; ok {
+		return
+	}
+
+	watcher := newDeploymentWatcher(w.logger, w.backend, d)
+	w.watchers[d.ID] = watcher
+	go watcher.watch()
+}
+
+// remove stops watching the given deployment. The lock must be held by the
+// caller.
+func (w *DeploymentWatcher) remove(deploymentID string) {
+	if watcher, ok := w.watchers[deploymentID]; ok {
+		watcher.stop()
+		delete(w.watchers, deploymentID)
+	}
+}
+
+// numWatchers returns the number of deployments currently being watched.
+func (w *DeploymentWatcher) numWatchers() int {
+	w.l.RLock()
+	defer w.l.RUnlock()
+	return len(w.watchers)
+}
+
+// deploymentWatcher follows a single active deployment, reacting to changes
+// in the health of its allocations.
+type deploymentWatcher struct {
+	deploymentID string
+	jobID        string
+	backend      DeploymentWatcherBackend
+	logger       *log.Logger
+
+	// healthy is the number of healthy allocations the watcher has last
+	// created an evaluation for.
+	healthy int
+
+	exitCh   chan struct{}
+	exitOnce sync.Once
+}
+
+// newDeploymentWatcher returns a watcher for the passed deployment.
+func newDeploymentWatcher(logger *log.Logger, backend DeploymentWatcherBackend, d *structs.Deployment) *deploymentWatcher {
+	return &deploymentWatcher{
+		deploymentID: d.ID,
+		jobID:        d.JobID,
+		backend:      backend,
+		logger:       logger,
+		exitCh:       make(chan struct{}),
+	}
+}
+
+// stop stops watching the deployment.
+func (w *deploymentWatcher) stop() {
+	w.exitOnce.Do(func() {
+		close(w.exitCh)
+	})
+}
+
+// watch blocks on changes to the deployment until it becomes terminal or the
+// watcher is stopped.
+func (w *deploymentWatcher) watch() {
+	index := uint64(0)
+	for {
+		d, newIndex, err := w.getDeployment(index)
+		if err == errDeploymentWatcherStopped {
+			return
+		} else if err != nil {
+			w.logger.Printf("[ERR] nomad.deployment_watcher: failed to retrieve deployment %q: %v", w.deploymentID, err)
+			select {
+			case <-w.exitCh:
+				return
+			case <-time.After(deploymentWatcherRetryInterval):
+			}
+			continue
+		}
+
+		// The deployment has been garbage collected or has become terminal so
+		// there is nothing left to drive.
+		if d == nil || !d.Active() {
+			return
+		}
+
+		if err := w.handleDeployment(d); err != nil {
+			w.logger.Printf("[ERR] nomad.deployment_watcher: failed to update deployment %q: %v", w.deploymentID, err)
+			select {
+			case <-w.exitCh:
+				return
+			case <-time.After(deploymentWatcherRetryInterval):
+			}
+			continue
+		}
+		index = newIndex
+	}
+}
+
+// getDeployment blocks until the deployment has been modified after the passed
+// index and returns it along with its modify index.
+func (w *deploymentWatcher) getDeployment(minIndex uint64) (*structs.Deployment, uint64, error) {
+	var deployment *structs.Deployment
+	index, err := blockingStateQuery(w.backend, w.exitCh, minIndex, func(ws memdb.WatchSet, state *state.StateStore) (uint64, error) {
+		d, err := state.DeploymentByID(ws, w.deploymentID)
+		if err != nil {
+			return 0, err
+		}
+
+		deployment = d
+		if d == nil {
+			// Return the table index so that a garbage collected deployment
+			// unblocks the query.
+			return state.Index("deployment")
+		}
+		return d.ModifyIndex, nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return deployment, index, nil
+}
+
+// handleDeployment reacts to the latest view of the deployment. Deployments
+// with unhealthy allocations are failed, deployments whose task groups are all
+// healthy are marked successful and otherwise an evaluation is created when
+// more allocations have become healthy so that the scheduler may continue the
+// rollout.
+func (w *deploymentWatcher) handleDeployment(d *structs.Deployment) error {
+	// Lookup the job to create evaluations for it
+	job, err := w.backend.State().JobByID(nil, w.jobID)
+	if err != nil {
+		return fmt.Errorf("failed to lookup job %q: %v", w.jobID, err)
+	}
+
+	switch {
+	case d.HasUnhealthyAllocs():
+		// Fail the deployment and create an evaluation so the scheduler
+		// reacts to the failure.
+		req := &structs.DeploymentStatusUpdateRequest{
+			DeploymentUpdate: &structs.DeploymentStatusUpdate{
+				DeploymentID:      d.ID,
+				Status:            structs.DeploymentStatusFailed,
+				StatusDescription: structs.DeploymentStatusDescriptionFailedAllocations,
+			},
+		}
+		if job != nil {
+			req.Eval = newDeploymentEval(job)
+		}
+		if _, err := w.backend.UpdateDeploymentStatus(req); err != nil {
+			return err
+		}
+		w.logger.Printf("[DEBUG] nomad.deployment_watcher: deployment %q failed due to unhealthy allocations", d.ID)
+		return nil
+
+	case d.Status == structs.DeploymentStatusPaused:
+		// Do not make progress on a paused deployment.
+		return nil
+
+	case d.Complete():
+		req := &structs.DeploymentStatusUpdateRequest{
+			DeploymentUpdate: &structs.DeploymentStatusUpdate{
+				DeploymentID:      d.ID,
+				Status:            structs.DeploymentStatusSuccessful,
+				StatusDescription: structs.DeploymentStatusDescriptionSuccessful,
+			},
+		}
+		if _, err := w.backend.UpdateDeploymentStatus(req); err != nil {
+			return err
+		}
+		w.logger.Printf("[DEBUG] nomad.deployment_watcher: deployment %q completed successfully", d.ID)
+		return nil
+	}
+
+	// Nothing to do if no further allocations have become healthy
+	healthy := d.HealthyAllocs()
+	if healthy <= w.healthy || job == nil {
+		return nil
+	}
+
+	// Create an evaluation so the scheduler can replace further allocations
+	eval := newDeploymentEval(job)
+	if _, err := w.backend.UpsertEvals([]*structs.Evaluation{eval}); err != nil {
+		return err
+	}
+	w.healthy = healthy
+	w.logger.Printf("[DEBUG] nomad.deployment_watcher: deployment %q has %d healthy allocations, created eval %q", d.ID, healthy, eval.ID)
+	return nil
+}
+
+// newDeploymentEval returns an evaluation for the passed job triggered by a
+// change to its deployment.
+func newDeploymentEval(job *structs.Job) *structs.Evaluation {
+	return &structs.Evaluation{
+		ID:             structs.GenerateUUID(),
+		Priority:       job.Priority,
+		Type:           job.Type,
+		TriggeredBy:    structs.EvalTriggerDeploymentWatcher,
+		JobID:          job.ID,
+		JobModifyIndex: job.JobModifyIndex,
+		Status:         structs.EvalStatusPending,
+	}
+}
+
+// blockingStateQuery runs the query against the backend's current state until
+// it returns an index greater than minIndex. It returns the index of the last
+// run query or errDeploymentWatcherStopped if the stop channel is closed.
+func blockingStateQuery(backend DeploymentWatcherBackend, stopCh <-chan struct{}, minIndex uint64,
+	query func(memdb.WatchSet, *state.StateStore) (uint64, error)) (uint64, error) {
+	for {
+		select {
+		case <-stopCh:
+			return 0, errDeploymentWatcherStopped
+		default:
+		}
+
+		// Capture the state store so that the query unblocks if it is
+		// abandoned because a snapshot was restored.
+		state := backend.State()
+		ws := memdb.NewWatchSet()
+		ws.Add(state.AbandonCh())
+		ws.Add(stopCh)
+
+		index, err := query(ws, state)
+		if err != nil {
+			return 0, err
+		}
+		if index > minIndex {
+			return index, nil
+		}
+
+		ws.Watch(nil)
+	}
+}
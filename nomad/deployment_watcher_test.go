@@ -0,0 +1,307 @@
+package nomad
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"testing"
+
+	memdb "github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/state"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+)
+
+// mockDeploymentWatcherBackend implements the DeploymentWatcherBackend by
+// applying updates directly to a state store.
+type mockDeploymentWatcherBackend struct {
+	state *state.StateStore
+	index uint64
+	l     sync.Mutex
+}
+
+func newMockDeploymentWatcherBackend(t *testing.T) *mockDeploymentWatcherBackend {
+	state, err := state.NewStateStore(os.Stderr)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return &mockDeploymentWatcherBackend{
+		state: state,
+		index: 1000,
+	}
+}
+
+func (m *mockDeploymentWatcherBackend) nextIndex() uint64 {
+	m.l.Lock()
+	defer m.l.Unlock()
+	m.index++
+	return m.index
+}
+
+func (m *mockDeploymentWatcherBackend) State() *state.StateStore {
+	return m.state
+}
+
+func (m *mockDeploymentWatcherBackend) UpsertEvals(evals []*structs.Evaluation) (uint64, error) {
+	index := m.nextIndex()
+	return index, m.state.UpsertEvals(index, evals)
+}
+
+func (m *mockDeploymentWatcherBackend) UpdateDeploymentStatus(req *structs.DeploymentStatusUpdateRequest) (uint64, error) {
+	index := m.nextIndex()
+	return index, m.state.UpdateDeploymentStatus(index, req)
+}
+
+// testDeploymentWatcher returns an enabled deployment watcher and its backend.
+func testDeploymentWatcher(t *testing.T) (*DeploymentWatcher, *mockDeploymentWatcherBackend) {
+	b := newMockDeploymentWatcherBackend(t)
+	w := NewDeploymentWatcher(log.New(os.Stderr, "", log.LstdFlags), b)
+	w.SetEnabled(true)
+	return w, b
+}
+
+// testDeploymentJob upserts a service job and a deployment tracking it.
+func testDeploymentJob(t *testing.T, b *mockDeploymentWatcherBackend) (*structs.Job, *structs.Deployment) {
+	job := mock.Job()
+	if err := b.state.UpsertJob(b.nextIndex(), job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	d := structs.NewDeployment(job)
+	d.TaskGroups["web"] = &structs.DeploymentState{DesiredTotal: 2}
+	if err := b.state.UpsertDeployment(b.nextIndex(), d, false); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return job, d
+}
+
+// setDeploymentHealth updates the allocation health counts of the deployment.
+func setDeploymentHealth(t *testing.T, b *mockDeploymentWatcherBackend, d *structs.Deployment, healthy, unhealthy int) {
+	out, err := b.state.DeploymentByID(nil, d.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out = out.Copy()
+	out.TaskGroups["web"].HealthyAllocs = healthy
+	out.TaskGroups["web"].UnhealthyAllocs = unhealthy
+	if err := b.state.UpsertDeployment(b.nextIndex(), out, false); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+// deploymentWatcherEvals returns the evaluations created by the deployment
+// watcher for the job.
+func deploymentWatcherEvals(b *mockDeploymentWatcherBackend, jobID string) ([]*structs.Evaluation, error) {
+	evals, err := b.state.EvalsByJob(nil, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*structs.Evaluation
+	for _, eval := range evals {
+		if eval.TriggeredBy == structs.EvalTriggerDeploymentWatcher {
+			out = append(out, eval)
+		}
+	}
+	return out, nil
+}
+
+func TestDeploymentWatcher_SetEnabled(t *testing.T) {
+	w, b := testDeploymentWatcher(t)
+	_, d := testDeploymentJob(t, b)
+
+	// Ensure the active deployment is watched
+	testutil.WaitForResult(func() (bool, error) {
+		return w.numWatchers() == 1, fmt.Errorf("expected 1 watcher; got %d", w.numWatchers())
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+
+	// Disabling the watcher stops watching the deployment
+	w.SetEnabled(false)
+	if w.Enabled() || w.numWatchers() != 0 {
+		t.Fatalf("expected no watchers; got %d", w.numWatchers())
+	}
+
+	// Re-enabling picks the deployment back up
+	w.SetEnabled(true)
+	defer w.SetEnabled(false)
+	testutil.WaitForResult(func() (bool, error) {
+		return w.numWatchers() == 1, fmt.Errorf("expected 1 watcher; got %d", w.numWatchers())
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+
+	// Cancelling the deployment stops watching it
+	req := &structs.DeploymentStatusUpdateRequest{
+		DeploymentUpdate: &structs.DeploymentStatusUpdate{
+			DeploymentID:      d.ID,
+			Status:            structs.DeploymentStatusCancelled,
+			StatusDescription: structs.DeploymentStatusDescriptionNewerJob,
+		},
+	}
+	if _, err := b.UpdateDeploymentStatus(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	testutil.WaitForResult(func() (bool, error) {
+		return w.numWatchers() == 0, fmt.Errorf("expected no watchers; got %d", w.numWatchers())
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+}
+
+func TestDeploymentWatcher_HealthyAllocs_CreatesEval(t *testing.T) {
+	w, b := testDeploymentWatcher(t)
+	defer w.SetEnabled(false)
+	job, d := testDeploymentJob(t, b)
+
+	// Mark an allocation as healthy
+	setDeploymentHealth(t, b, d, 1, 0)
+
+	// Ensure an evaluation is created for the job
+	testutil.WaitForResult(func() (bool, error) {
+		evals, err := deploymentWatcherEvals(b, job.ID)
+		if err != nil {
+			return false, err
+		}
+		if len(evals) != 1 {
+			return false, fmt.Errorf("expected 1 eval; got %d", len(evals))
+		}
+		if evals[0].JobModifyIndex != job.JobModifyIndex || evals[0].Type != job.Type {
+			return false, fmt.Errorf("bad eval: %#v", evals[0])
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+
+	// The deployment should still be running
+	ws := memdb.NewWatchSet()
+	out, err := b.state.DeploymentByID(ws, d.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.Status != structs.DeploymentStatusRunning {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
+func TestDeploymentWatcher_UnhealthyAllocs_FailsDeployment(t *testing.T) {
+	w, b := testDeploymentWatcher(t)
+	defer w.SetEnabled(false)
+	job, d := testDeploymentJob(t, b)
+
+	// Mark an allocation as unhealthy
+	setDeploymentHealth(t, b, d, 0, 1)
+
+	// Ensure the deployment is failed and an evaluation created
+	testutil.WaitForResult(func() (bool, error) {
+		out, err := b.state.DeploymentByID(nil, d.ID)
+		if err != nil {
+			return false, err
+		}
+		if out.Status != structs.DeploymentStatusFailed {
+			return false, fmt.Errorf("expected failed deployment; got %q", out.Status)
+		}
+		if out.StatusDescription != structs.DeploymentStatusDescriptionFailedAllocations {
+			return false, fmt.Errorf("bad description: %q", out.StatusDescription)
+		}
+
+		evals, err := deploymentWatcherEvals(b, job.ID)
+		if err != nil {
+			return false, err
+		}
+		if len(evals) != 1 {
+			return false, fmt.Errorf("expected 1 eval; got %d", len(evals))
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+
+	// The job should not be marked as stable
+	out, err := b.state.JobByID(nil, job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.Stable {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
+func TestDeploymentWatcher_Complete_MarksJobStable(t *testing.T) {
+	w, b := testDeploymentWatcher(t)
+	defer w.SetEnabled(false)
+	job, d := testDeploymentJob(t, b)
+
+	// Mark all the allocations as healthy
+	setDeploymentHealth(t, b, d, 2, 0)
+
+	// Ensure the deployment is successful and the job is stable
+	testutil.WaitForResult(func() (bool, error) {
+		out, err := b.state.DeploymentByID(nil, d.ID)
+		if err != nil {
+			return false, err
+		}
+		if out.Status != structs.DeploymentStatusSuccessful {
+			return false, fmt.Errorf("expected successful deployment; got %q", out.Status)
+		}
+
+		jout, err := b.state.JobByID(nil, job.ID)
+		if err != nil {
+			return false, err
+		}
+		if !jout.Stable {
+			return false, fmt.Errorf("expected stable job")
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+
+	// The deployment should no longer be watched
+	testutil.WaitForResult(func() (bool, error) {
+		return w.numWatchers() == 0, fmt.Errorf("expected no watchers; got %d", w.numWatchers())
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+}
+
+func TestDeploymentWatcher_Paused(t *testing.T) {
+	w, b := testDeploymentWatcher(t)
+	defer w.SetEnabled(false)
+	job, d := testDeploymentJob(t, b)
+
+	// Wait for the deployment to be watched
+	testutil.WaitForResult(func() (bool, error) {
+		return w.numWatchers() == 1, fmt.Errorf("expected 1 watcher; got %d", w.numWatchers())
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+
+	// Pause the deployment and mark an allocation as healthy
+	out, err := b.state.DeploymentByID(nil, d.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out = out.Copy()
+	out.Status = structs.DeploymentStatusPaused
+	out.TaskGroups["web"].HealthyAllocs = 1
+	if err := b.state.UpsertDeployment(b.nextIndex(), out, false); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure no evaluation is created while paused
+	testutil.WaitForResult(func() (bool, error) {
+		evals, err := deploymentWatcherEvals(b, job.ID)
+		if err != nil {
+			return false, err
+		}
+		return len(evals) == 0, fmt.Errorf("expected no evals; got %d", len(evals))
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+}
@@ -1577,3 +1577,112 @@ func TestFSM_ApplyPlanResults(t *testing.T) {
 		t.Fatalf("bad job")
 	}
 }
+
+func TestFSM_DeploymentStatusUpdate(t *testing.T) {
+	fsm := testFSM(t)
+	fsm.evalBroker.SetEnabled(true)
+	state := fsm.State()
+
+	// Upsert a deployment
+	d := mock.Deployment()
+	if err := state.UpsertDeployment(1, d, false); err != nil {
+		t.Fatalf("bad: %v", err)
+	}
+
+	// Create a request to update the deployment and create an eval
+	status, description := structs.DeploymentStatusFailed, "foo"
+	req := &structs.DeploymentStatusUpdateRequest{
+		DeploymentUpdate: &structs.DeploymentStatusUpdate{
+			DeploymentID:      d.ID,
+			Status:            status,
+			StatusDescription: description,
+		},
+		Eval: mock.Eval(),
+	}
+	buf, err := structs.Encode(structs.DeploymentStatusUpdateRequestType, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	resp := fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	// Check that the status was updated properly
+	ws := memdb.NewWatchSet()
+	dout, err := state.DeploymentByID(ws, d.ID)
+	if err != nil {
+		t.Fatalf("bad: %v", err)
+	}
+	if dout.Status != status || dout.StatusDescription != description {
+		t.Fatalf("bad: %#v", dout)
+	}
+
+	// Check that the evaluation was created
+	eout, _ := state.EvalByID(ws, req.Eval.ID)
+	if eout == nil {
+		t.Fatalf("bad: %#v", eout)
+	}
+
+	// Verify enqueued
+	stats := fsm.evalBroker.Stats()
+	if stats.TotalReady != 1 {
+		t.Fatalf("bad: %#v %#v", stats, eout)
+	}
+}
+
+func TestFSM_DeploymentPromotion(t *testing.T) {
+	fsm := testFSM(t)
+	fsm.evalBroker.SetEnabled(true)
+	state := fsm.State()
+
+	// Upsert a deployment with canaries that are ready to be promoted
+	d := mock.Deployment()
+	d.TaskGroups["web"].RequiresPromotion = true
+	d.TaskGroups["web"].DesiredCanaries = 1
+	d.TaskGroups["web"].HealthyAllocs = 1
+	if err := state.UpsertDeployment(1, d, false); err != nil {
+		t.Fatalf("bad: %v", err)
+	}
+
+	// Create a request to promote the deployment and create an eval
+	req := &structs.ApplyDeploymentPromoteRequest{
+		DeploymentPromoteRequest: structs.DeploymentPromoteRequest{
+			DeploymentID: d.ID,
+			All:          true,
+		},
+		Eval: mock.Eval(),
+	}
+	buf, err := structs.Encode(structs.DeploymentPromoteRequestType, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	resp := fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	// Check that the deployment was promoted
+	ws := memdb.NewWatchSet()
+	dout, err := state.DeploymentByID(ws, d.ID)
+	if err != nil {
+		t.Fatalf("bad: %v", err)
+	}
+	if !dout.TaskGroups["web"].Promoted {
+		t.Fatalf("bad: %#v", dout.TaskGroups["web"])
+	}
+
+	// Check that the evaluation was created
+	eout, _ := state.EvalByID(ws, req.Eval.ID)
+	if eout == nil {
+		t.Fatalf("bad: %#v", eout)
+	}
+
+	// Verify enqueued
+	stats := fsm.evalBroker.Stats()
+	if stats.TotalReady != 1 {
+		t.Fatalf("bad: %#v %#v", stats, eout)
+	}
+}
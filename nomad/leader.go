@@ -151,6 +151,9 @@ func (s *Server) establishLeadership(stopCh chan struct{}) error {
 		return err
 	}
 
+	// Enable the deployment watcher, since we are now the leader
+	s.deploymentWatcher.SetEnabled(true)
+
 	// Scheduler periodic jobs
 	go s.schedulePeriodic(stopCh)
 
@@ -480,6 +483,9 @@ func (s *Server) revokeLeadership() error {
 	// Disable the periodic dispatcher, since it is only useful as a leader
 	s.periodicDispatcher.SetEnabled(false)
 
+	// Disable the deployment watcher, since it is only useful as a leader
+	s.deploymentWatcher.SetEnabled(false)
+
 	// Disable the Vault client as it is only useful as a leader.
 	s.vault.SetActive(false)
 
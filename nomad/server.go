@@ -139,6 +139,10 @@ type Server struct {
 	// periodicDispatcher is used to track and create evaluations for periodic jobs.
 	periodicDispatcher *PeriodicDispatch
 
+	// deploymentWatcher is used to watch deployments and their allocations and
+	// drive the deployments to completion.
+	deploymentWatcher *DeploymentWatcher
+
 	// heartbeatTimers track the expiration time of each heartbeat that has
 	// a TTL. On expiration, the node status is updated to be 'down'.
 	heartbeatTimers     map[string]*time.Timer
@@ -161,16 +165,17 @@ type Server struct {
 
 // Holds the RPC endpoints
 type endpoints struct {
-	Status   *Status
-	Node     *Node
-	Job      *Job
-	Eval     *Eval
-	Plan     *Plan
-	Alloc    *Alloc
-	Region   *Region
-	Periodic *Periodic
-	System   *System
-	Operator *Operator
+	Status     *Status
+	Node       *Node
+	Job        *Job
+	Eval       *Eval
+	Plan       *Plan
+	Alloc      *Alloc
+	Deployment *Deployment
+	Region     *Region
+	Periodic   *Periodic
+	System     *System
+	Operator   *Operator
 }
 
 // NewServer is used to construct a new Nomad server from the
@@ -239,6 +244,9 @@ func NewServer(config *Config, consulCatalog consul.CatalogAPI, logger *log.Logg
 	// Create the periodic dispatcher for launching periodic jobs.
 	s.periodicDispatcher = NewPeriodicDispatch(s.logger, s)
 
+	// Create the deployment watcher to drive deployments to completion.
+	s.deploymentWatcher = NewDeploymentWatcher(s.logger, s)
+
 	// Setup Vault
 	if err := s.setupVaultClient(); err != nil {
 		s.Shutdown()
@@ -676,6 +684,7 @@ func (s *Server) setupVaultClient() error {
 func (s *Server) setupRPC(tlsWrap tlsutil.RegionWrapper) error {
 	// Create endpoints
 	s.endpoints.Alloc = &Alloc{s}
+	s.endpoints.Deployment = &Deployment{s}
 	s.endpoints.Eval = &Eval{s}
 	s.endpoints.Job = &Job{s}
 	s.endpoints.Node = &Node{srv: s}
@@ -688,6 +697,7 @@ func (s *Server) setupRPC(tlsWrap tlsutil.RegionWrapper) error {
 
 	// Register the handlers
 	s.rpcServer.Register(s.endpoints.Alloc)
+	s.rpcServer.Register(s.endpoints.Deployment)
 	s.rpcServer.Register(s.endpoints.Eval)
 	s.rpcServer.Register(s.endpoints.Job)
 	s.rpcServer.Register(s.endpoints.Node)
@@ -99,7 +99,9 @@ func (s *StateStore) UpsertPlanResults(index uint64, results *structs.ApplyPlanR
 
 	// Update the status of deployments effected by the plan.
 	if len(results.DeploymentUpdates) != 0 {
-		s.upsertDeploymentUpdates(index, results.DeploymentUpdates, txn)
+		if err := s.upsertDeploymentUpdates(index, results.DeploymentUpdates, txn); err != nil {
+			return err
+		}
 	}
 
 	// Attach the job to all the allocations. It is pulled out in the payload to
@@ -136,32 +138,12 @@ func (s *StateStore) UpsertPlanResults(index uint64, results *structs.ApplyPlanR
 // upsertDeploymentUpdates updates the deployments given the passed status
 // updates.
 func (s *StateStore) upsertDeploymentUpdates(index uint64, updates []*structs.DeploymentStatusUpdate, txn *memdb.Txn) error {
-	for _, d := range updates {
-		raw, err := txn.First("deployment", "id", d.DeploymentID)
-		if err != nil {
-			return err
-		}
-		if raw == nil {
-			return fmt.Errorf("Deployment ID %q couldn't be updated as it does not exist", d.DeploymentID)
-		}
-
-		copy := raw.(*structs.Deployment).Copy()
-
-		// Apply the new status
-		copy.Status = d.Status
-		copy.StatusDescription = d.StatusDescription
-		copy.ModifyIndex = index
-
-		// Insert the deployment
-		if err := txn.Insert("deployment", copy); err != nil {
+	for _, u := range updates {
+		if err := s.updateDeploymentStatusImpl(index, u, txn); err != nil {
 			return err
 		}
 	}
 
-	if err := txn.Insert("index", &IndexEntry{"deployment", index}); err != nil {
-		return fmt.Errorf("index update failed: %v", err)
-	}
-
 	return nil
 }
 
@@ -308,6 +290,20 @@ func (s *StateStore) Deployments(ws memdb.WatchSet) (memdb.ResultIterator, error
 	return iter, nil
 }
 
+// DeploymentsByIDPrefix is used to lookup deployments by prefix
+func (s *StateStore) DeploymentsByIDPrefix(ws memdb.WatchSet, deploymentID string) (memdb.ResultIterator, error) {
+	txn := s.db.Txn(false)
+
+	// Walk the entire deployments table
+	iter, err := txn.Get("deployment", "id_prefix", deploymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	ws.Add(iter.WatchCh())
+	return iter, nil
+}
+
 func (s *StateStore) DeploymentByID(ws memdb.WatchSet, deploymentID string) (*structs.Deployment, error) {
 	txn := s.db.Txn(false)
 	return s.deploymentByIDImpl(ws, deploymentID, txn)
@@ -407,6 +403,192 @@ func (s *StateStore) DeleteDeployment(index uint64, deploymentID string) error {
 	return nil
 }
 
+// UpdateDeploymentStatus is used to make deployment status updates and
+// potentially make a evaluation
+func (s *StateStore) UpdateDeploymentStatus(index uint64, req *structs.DeploymentStatusUpdateRequest) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	// Only active deployments may have their status updated
+	ws := memdb.NewWatchSet()
+	deployment, err := s.deploymentByIDImpl(ws, req.DeploymentUpdate.DeploymentID, txn)
+	if err != nil {
+		return err
+	} else if deployment != nil && !deployment.Active() {
+		return fmt.Errorf("Deployment %q has terminal status %q", deployment.ID, deployment.Status)
+	}
+
+	if err := s.updateDeploymentStatusImpl(index, req.DeploymentUpdate, txn); err != nil {
+		return err
+	}
+
+	// Upsert the evaluation
+	if req.Eval != nil {
+		if err := s.nestedUpsertEval(txn, index, req.Eval); err != nil {
+			return err
+		}
+	}
+
+	txn.Commit()
+	return nil
+}
+
+// updateDeploymentStatusImpl is used to make deployment status updates
+func (s *StateStore) updateDeploymentStatusImpl(index uint64, u *structs.DeploymentStatusUpdate, txn *memdb.Txn) error {
+	// Retrieve deployment
+	ws := memdb.NewWatchSet()
+	deployment, err := s.deploymentByIDImpl(ws, u.DeploymentID, txn)
+	if err != nil {
+		return err
+	} else if deployment == nil {
+		return fmt.Errorf("Deployment ID %q couldn't be updated as it does not exist", u.DeploymentID)
+	}
+
+	// Copy and update the existing deployment
+	copy := deployment.Copy()
+	copy.Status = u.Status
+	copy.StatusDescription = u.StatusDescription
+	copy.ModifyIndex = index
+
+	// Insert the deployment
+	if err := txn.Insert("deployment", copy); err != nil {
+		return err
+	}
+
+	// Update the index
+	if err := txn.Insert("index", &IndexEntry{"deployment", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	// If the deployment is being marked as complete, set the job to stable.
+	if copy.Status == structs.DeploymentStatusSuccessful {
+		if err := s.updateJobStabilityImpl(index, copy.JobID, copy.JobVersion, true, txn); err != nil {
+			return fmt.Errorf("failed to update job stability: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// UpdateDeploymentPromotion is used to promote the canaries in a deployment and
+// potentially make a evaluation
+func (s *StateStore) UpdateDeploymentPromotion(index uint64, req *structs.ApplyDeploymentPromoteRequest) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	// Retrieve deployment and ensure it is not terminal and is active
+	ws := memdb.NewWatchSet()
+	deployment, err := s.deploymentByIDImpl(ws, req.DeploymentID, txn)
+	if err != nil {
+		return err
+	} else if deployment == nil {
+		return fmt.Errorf("Deployment ID %q does not exist", req.DeploymentID)
+	} else if !deployment.Active() {
+		return fmt.Errorf("Deployment %q has terminal status %q", deployment.ID, deployment.Status)
+	}
+
+	// Determine the task groups to promote
+	groups := req.Groups
+	if req.All {
+		groups = nil
+		for tg, state := range deployment.TaskGroups {
+			if state.RequiresPromotion {
+				groups = append(groups, tg)
+			}
+		}
+	}
+	if len(groups) == 0 {
+		return fmt.Errorf("Deployment %q has no canaries to promote", deployment.ID)
+	}
+
+	// Ensure every group to promote has a healthy set of canaries
+	copy := deployment.Copy()
+	copy.ModifyIndex = index
+	for _, tg := range groups {
+		state, ok := copy.TaskGroups[tg]
+		if !ok {
+			return fmt.Errorf("Deployment %q does not include task group %q", deployment.ID, tg)
+		} else if !state.RequiresPromotion {
+			return fmt.Errorf("Task group %q does not require promotion", tg)
+		} else if state.HealthyAllocs < state.DesiredCanaries {
+			return fmt.Errorf("Task group %q has %d/%d healthy allocations", tg, state.HealthyAllocs, state.DesiredCanaries)
+		}
+
+		state.Promoted = true
+	}
+
+	// Insert the deployment
+	if err := txn.Insert("deployment", copy); err != nil {
+		return err
+	}
+
+	// Update the index
+	if err := txn.Insert("index", &IndexEntry{"deployment", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	// Upsert the evaluation
+	if req.Eval != nil {
+		if err := s.nestedUpsertEval(txn, index, req.Eval); err != nil {
+			return err
+		}
+	}
+
+	txn.Commit()
+	return nil
+}
+
+// updateJobStabilityImpl updates the stability of the passed job version
+func (s *StateStore) updateJobStabilityImpl(index uint64, jobID string, jobVersion uint64, stable bool, txn *memdb.Txn) error {
+	// Get the job that is referenced
+	raw, err := txn.First("job_version", "id", jobID, jobVersion)
+	if err != nil {
+		return err
+	}
+
+	// Has already been cleared, nothing to do
+	if raw == nil {
+		return nil
+	}
+
+	job := raw.(*structs.Job)
+	if job.Stable == stable {
+		return nil
+	}
+
+	// Update the job version in place with the new stability
+	copy := job.Copy()
+	copy.Stable = stable
+	if err := txn.Insert("job_version", copy); err != nil {
+		return fmt.Errorf("failed to update job version: %v", err)
+	}
+	if err := txn.Insert("index", &IndexEntry{"job_version", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	// If the version is the current version of the job, update the job
+	// itself so the two are kept in sync.
+	existing, err := txn.First("jobs", "id", jobID)
+	if err != nil {
+		return fmt.Errorf("job lookup failed: %v", err)
+	}
+	if existing == nil || existing.(*structs.Job).Version != jobVersion {
+		return nil
+	}
+
+	current := existing.(*structs.Job).Copy()
+	current.Stable = stable
+	current.ModifyIndex = index
+	if err := txn.Insert("jobs", current); err != nil {
+		return fmt.Errorf("job insert failed: %v", err)
+	}
+	if err := txn.Insert("index", &IndexEntry{"jobs", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	return nil
+}
+
 // UpsertNode is used to register a node or update a node definition
 // This is assumed to be triggered by the client, so we retain the value
 // of drain which is set by the scheduler.
@@ -1675,6 +1857,29 @@ func (s *StateStore) AllocsByEval(ws memdb.WatchSet, evalID string) ([]*structs.
 	return out, nil
 }
 
+// AllocsByDeployment returns all the allocations by deployment id
+func (s *StateStore) AllocsByDeployment(ws memdb.WatchSet, deploymentID string) ([]*structs.Allocation, error) {
+	txn := s.db.Txn(false)
+
+	// Get an iterator over the deployments allocations
+	iter, err := txn.Get("allocs", "deployment", deploymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	ws.Add(iter.WatchCh())
+
+	var out []*structs.Allocation
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+		out = append(out, raw.(*structs.Allocation))
+	}
+	return out, nil
+}
+
 // Allocs returns an iterator over all the evaluations
 func (s *StateStore) Allocs(ws memdb.WatchSet) (memdb.ResultIterator, error) {
 	txn := s.db.Txn(false)
@@ -2227,10 +2432,6 @@ func (s *StateStore) updateDeploymentWithAlloc(index uint64, alloc, existing *st
 
 	// Do not modify in-place. Instead keep track of what must be done
 	placed := 0
-
-	// TODO test when I am sure of what this method will do
-	// XXX Unclear whether this will be helpful because a seperate code path is
-	// likely need for setting health
 	healthy := 0
 	unhealthy := 0
 
@@ -2263,11 +2464,6 @@ func (s *StateStore) updateDeploymentWithAlloc(index uint64, alloc, existing *st
 	deploymentCopy := deployment.Copy()
 	deploymentCopy.ModifyIndex = index
 
-	if unhealthy != 0 {
-		deploymentCopy.Status = structs.DeploymentStatusFailed
-		deploymentCopy.StatusDescription = "Allocation(s) marked as unhealthy"
-	}
-
 	state := deploymentCopy.TaskGroups[alloc.TaskGroup]
 	state.PlacedAllocs += placed
 	state.HealthyAllocs += healthy
@@ -510,6 +510,338 @@ func TestStateStore_Deployments(t *testing.T) {
 	}
 }
 
+func TestStateStore_UpdateDeploymentStatus_Nonexistent(t *testing.T) {
+	state := testStateStore(t)
+
+	// Update the nonexistent deployment
+	req := &structs.DeploymentStatusUpdateRequest{
+		DeploymentUpdate: &structs.DeploymentStatusUpdate{
+			DeploymentID: structs.GenerateUUID(),
+			Status:       structs.DeploymentStatusRunning,
+		},
+	}
+	err := state.UpdateDeploymentStatus(2, req)
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Fatalf("expected error updating the status because the deployment doesn't exist")
+	}
+}
+
+func TestStateStore_UpdateDeploymentStatus_Terminal(t *testing.T) {
+	state := testStateStore(t)
+
+	// Insert a terminal deployment
+	d := mock.Deployment()
+	d.Status = structs.DeploymentStatusFailed
+
+	if err := state.UpsertDeployment(1, d, false); err != nil {
+		t.Fatalf("bad: %v", err)
+	}
+
+	// Update the deployment
+	req := &structs.DeploymentStatusUpdateRequest{
+		DeploymentUpdate: &structs.DeploymentStatusUpdate{
+			DeploymentID: d.ID,
+			Status:       structs.DeploymentStatusRunning,
+		},
+	}
+	err := state.UpdateDeploymentStatus(2, req)
+	if err == nil || !strings.Contains(err.Error(), "has terminal status") {
+		t.Fatalf("expected error updating the status because the deployment is terminal")
+	}
+}
+
+func TestStateStore_UpdateDeploymentStatus_NonTerminal(t *testing.T) {
+	state := testStateStore(t)
+
+	// Insert a deployment
+	d := mock.Deployment()
+	if err := state.UpsertDeployment(1, d, false); err != nil {
+		t.Fatalf("bad: %v", err)
+	}
+
+	// Create an eval
+	e := mock.Eval()
+
+	// Update the deployment
+	status, desc := structs.DeploymentStatusFailed, "foo"
+	req := &structs.DeploymentStatusUpdateRequest{
+		DeploymentUpdate: &structs.DeploymentStatusUpdate{
+			DeploymentID:      d.ID,
+			Status:            status,
+			StatusDescription: desc,
+		},
+		Eval: e,
+	}
+	if err := state.UpdateDeploymentStatus(2, req); err != nil {
+		t.Fatalf("bad: %v", err)
+	}
+
+	// Check that the status was updated properly
+	ws := memdb.NewWatchSet()
+	dout, err := state.DeploymentByID(ws, d.ID)
+	if err != nil {
+		t.Fatalf("bad: %v", err)
+	}
+	if dout.Status != status || dout.StatusDescription != desc || dout.ModifyIndex != 2 {
+		t.Fatalf("bad: %#v", dout)
+	}
+
+	// Check that the evaluation was created
+	eout, err := state.EvalByID(ws, e.ID)
+	if err != nil {
+		t.Fatalf("bad: %v", err)
+	}
+	if eout == nil {
+		t.Fatalf("bad: %#v", eout)
+	}
+}
+
+func TestStateStore_UpdateDeploymentStatus_Successful(t *testing.T) {
+	state := testStateStore(t)
+
+	// Insert a job
+	job := mock.Job()
+	if err := state.UpsertJob(1, job); err != nil {
+		t.Fatalf("bad: %v", err)
+	}
+
+	// Insert a deployment
+	d := structs.NewDeployment(job)
+	if err := state.UpsertDeployment(2, d, false); err != nil {
+		t.Fatalf("bad: %v", err)
+	}
+
+	// Update the deployment
+	req := &structs.DeploymentStatusUpdateRequest{
+		DeploymentUpdate: &structs.DeploymentStatusUpdate{
+			DeploymentID:      d.ID,
+			Status:            structs.DeploymentStatusSuccessful,
+			StatusDescription: structs.DeploymentStatusDescriptionSuccessful,
+		},
+	}
+	if err := state.UpdateDeploymentStatus(3, req); err != nil {
+		t.Fatalf("bad: %v", err)
+	}
+
+	// Check that the status was updated properly
+	ws := memdb.NewWatchSet()
+	dout, err := state.DeploymentByID(ws, d.ID)
+	if err != nil {
+		t.Fatalf("bad: %v", err)
+	}
+	if dout.Status != structs.DeploymentStatusSuccessful ||
+		dout.StatusDescription != structs.DeploymentStatusDescriptionSuccessful {
+		t.Fatalf("bad: %#v", dout)
+	}
+
+	// Check that the job was marked as stable
+	jout, _ := state.JobByID(ws, job.ID)
+	if jout == nil || !jout.Stable || jout.ModifyIndex != 3 {
+		t.Fatalf("bad: %#v", jout)
+	}
+
+	// Check that the job version was marked as stable
+	vout, _ := state.JobByIDAndVersion(ws, job.ID, job.Version)
+	if vout == nil || !vout.Stable {
+		t.Fatalf("bad: %#v", vout)
+	}
+}
+
+func TestStateStore_DeploymentsByIDPrefix(t *testing.T) {
+	state := testStateStore(t)
+	deploy := mock.Deployment()
+
+	deploy.ID = "11111111-662e-d0ab-d1c9-3e434af7bdb4"
+	err := state.UpsertDeployment(1000, deploy, false)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Create a watchset so we can test that getters don't cause it to fire
+	ws := memdb.NewWatchSet()
+	iter, err := state.DeploymentsByIDPrefix(ws, deploy.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	gatherDeploys := func(iter memdb.ResultIterator) []*structs.Deployment {
+		var deploys []*structs.Deployment
+		for {
+			raw := iter.Next()
+			if raw == nil {
+				break
+			}
+			deploy := raw.(*structs.Deployment)
+			deploys = append(deploys, deploy)
+		}
+		return deploys
+	}
+
+	deploys := gatherDeploys(iter)
+	if len(deploys) != 1 {
+		t.Fatalf("err: %v", err)
+	}
+
+	if watchFired(ws) {
+		t.Fatalf("bad")
+	}
+
+	iter, err = state.DeploymentsByIDPrefix(ws, "11")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	deploys = gatherDeploys(iter)
+	if len(deploys) != 1 {
+		t.Fatalf("err: %v", err)
+	}
+
+	deploy = mock.Deployment()
+	deploy.ID = "11222222-662e-d0ab-d1c9-3e434af7bdb4"
+	err = state.UpsertDeployment(1001, deploy, false)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if !watchFired(ws) {
+		t.Fatalf("bad")
+	}
+
+	ws = memdb.NewWatchSet()
+	iter, err = state.DeploymentsByIDPrefix(ws, "11")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	deploys = gatherDeploys(iter)
+	if len(deploys) != 2 {
+		t.Fatalf("err: %v", err)
+	}
+
+	iter, err = state.DeploymentsByIDPrefix(ws, "1111")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	deploys = gatherDeploys(iter)
+	if len(deploys) != 1 {
+		t.Fatalf("err: %v", err)
+	}
+
+	if watchFired(ws) {
+		t.Fatalf("bad")
+	}
+}
+
+func TestStateStore_UpdateDeploymentPromotion_Terminal(t *testing.T) {
+	state := testStateStore(t)
+
+	// Insert a terminal deployment
+	d := mock.Deployment()
+	d.Status = structs.DeploymentStatusFailed
+
+	if err := state.UpsertDeployment(1, d, false); err != nil {
+		t.Fatalf("bad: %v", err)
+	}
+
+	// Promote the deployment
+	req := &structs.ApplyDeploymentPromoteRequest{
+		DeploymentPromoteRequest: structs.DeploymentPromoteRequest{
+			DeploymentID: d.ID,
+			All:          true,
+		},
+	}
+	err := state.UpdateDeploymentPromotion(2, req)
+	if err == nil || !strings.Contains(err.Error(), "has terminal status") {
+		t.Fatalf("expected error promoting because the deployment is terminal: %v", err)
+	}
+}
+
+func TestStateStore_UpdateDeploymentPromotion_NoCanaries(t *testing.T) {
+	state := testStateStore(t)
+
+	// Insert a deployment that doesn't require promotion
+	d := mock.Deployment()
+	if err := state.UpsertDeployment(1, d, false); err != nil {
+		t.Fatalf("bad: %v", err)
+	}
+
+	// Promote the deployment
+	req := &structs.ApplyDeploymentPromoteRequest{
+		DeploymentPromoteRequest: structs.DeploymentPromoteRequest{
+			DeploymentID: d.ID,
+			All:          true,
+		},
+	}
+	err := state.UpdateDeploymentPromotion(2, req)
+	if err == nil || !strings.Contains(err.Error(), "no canaries to promote") {
+		t.Fatalf("expected error promoting because there are no canaries: %v", err)
+	}
+}
+
+func TestStateStore_UpdateDeploymentPromotion_Groups(t *testing.T) {
+	state := testStateStore(t)
+
+	// Insert a deployment with two groups requiring promotion, only one of
+	// which has healthy canaries
+	d := mock.Deployment()
+	d.TaskGroups["web"].RequiresPromotion = true
+	d.TaskGroups["web"].DesiredCanaries = 1
+	d.TaskGroups["web"].HealthyAllocs = 1
+	d.TaskGroups["foo"] = &structs.DeploymentState{
+		RequiresPromotion: true,
+		DesiredCanaries:   1,
+		DesiredTotal:      2,
+	}
+	if err := state.UpsertDeployment(1, d, false); err != nil {
+		t.Fatalf("bad: %v", err)
+	}
+
+	// Promoting all fails because of the unhealthy group
+	req := &structs.ApplyDeploymentPromoteRequest{
+		DeploymentPromoteRequest: structs.DeploymentPromoteRequest{
+			DeploymentID: d.ID,
+			All:          true,
+		},
+	}
+	err := state.UpdateDeploymentPromotion(2, req)
+	if err == nil || !strings.Contains(err.Error(), "0/1 healthy allocations") {
+		t.Fatalf("expected error promoting because of unhealthy canaries: %v", err)
+	}
+
+	// Promote only the healthy group
+	e := mock.Eval()
+	req = &structs.ApplyDeploymentPromoteRequest{
+		DeploymentPromoteRequest: structs.DeploymentPromoteRequest{
+			DeploymentID: d.ID,
+			Groups:       []string{"web"},
+		},
+		Eval: e,
+	}
+	if err := state.UpdateDeploymentPromotion(3, req); err != nil {
+		t.Fatalf("bad: %v", err)
+	}
+
+	// Check that the group was promoted
+	ws := memdb.NewWatchSet()
+	dout, err := state.DeploymentByID(ws, d.ID)
+	if err != nil {
+		t.Fatalf("bad: %v", err)
+	}
+	if !dout.TaskGroups["web"].Promoted || dout.TaskGroups["foo"].Promoted || dout.ModifyIndex != 3 {
+		t.Fatalf("bad: %#v", dout.TaskGroups)
+	}
+
+	// Check that the evaluation was created
+	eout, err := state.EvalByID(ws, e.ID)
+	if err != nil {
+		t.Fatalf("bad: %v", err)
+	}
+	if eout == nil {
+		t.Fatalf("bad: %#v", eout)
+	}
+}
+
 func TestStateStore_UpsertNode_Node(t *testing.T) {
 	state := testStateStore(t)
 	node := mock.Node()
@@ -3949,6 +4281,45 @@ func TestStateStore_AllocsByJob(t *testing.T) {
 	}
 }
 
+func TestStateStore_AllocsByDeployment(t *testing.T) {
+	state := testStateStore(t)
+	var allocs []*structs.Allocation
+
+	d := mock.Deployment()
+	if err := state.UpsertDeployment(800, d, false); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		alloc := mock.Alloc()
+		if i < 5 {
+			alloc.DeploymentID = d.ID
+			allocs = append(allocs, alloc)
+		}
+		state.UpsertJobSummary(uint64(900+i), mock.JobSummary(alloc.JobID))
+		if err := state.UpsertAllocs(uint64(1000+i), []*structs.Allocation{alloc}); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	ws := memdb.NewWatchSet()
+	out, err := state.AllocsByDeployment(ws, d.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	sort.Sort(AllocIDSort(allocs))
+	sort.Sort(AllocIDSort(out))
+
+	if !reflect.DeepEqual(allocs, out) {
+		t.Fatalf("bad: %#v %#v", allocs, out)
+	}
+
+	if watchFired(ws) {
+		t.Fatalf("bad")
+	}
+}
+
 func TestStateStore_AllocsForRegisteredJob(t *testing.T) {
 	state := testStateStore(t)
 	var allocs []*structs.Allocation
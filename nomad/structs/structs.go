@@ -54,6 +54,8 @@ const (
 	VaultAccessorRegisterRequestType
 	VaultAccessorDegisterRequestType
 	ApplyPlanResultsRequestType
+	DeploymentStatusUpdateRequestType
+	DeploymentPromoteRequestType
 )
 
 const (
@@ -379,6 +381,75 @@ type ApplyPlanResultsRequest struct {
 	DeploymentUpdates []*DeploymentStatusUpdate
 }
 
+// DeploymentStatusUpdateRequest is used to update the status of a deployment
+// as well as optionally creating an evaluation atomically.
+type DeploymentStatusUpdateRequest struct {
+	// Eval, if set, is used to create an evaluation at the same time as
+	// updating the status of a deployment.
+	Eval *Evaluation
+
+	// DeploymentUpdate is a status update to apply to the given
+	// deployment.
+	DeploymentUpdate *DeploymentStatusUpdate
+
+	WriteRequest
+}
+
+// DeploymentListRequest is used to list the deployments
+type DeploymentListRequest struct {
+	QueryOptions
+}
+
+// DeploymentSpecificRequest is used to make a request specific to a particular
+// deployment
+type DeploymentSpecificRequest struct {
+	DeploymentID string
+	QueryOptions
+}
+
+// DeploymentPauseRequest is used to pause or resume a deployment
+type DeploymentPauseRequest struct {
+	// DeploymentID is the deployment to pause or resume
+	DeploymentID string
+
+	// Pause sets the pause status of the deployment
+	Pause bool
+
+	WriteRequest
+}
+
+// DeploymentPromoteRequest is used to promote the canaries of a deployment
+type DeploymentPromoteRequest struct {
+	// DeploymentID is the deployment to promote
+	DeploymentID string
+
+	// All is to promote all task groups
+	All bool
+
+	// Groups is used to set the promotion status per task group
+	Groups []string
+
+	WriteRequest
+}
+
+// DeploymentFailRequest is used to fail a particular deployment
+type DeploymentFailRequest struct {
+	// DeploymentID is the deployment to fail
+	DeploymentID string
+
+	WriteRequest
+}
+
+// ApplyDeploymentPromoteRequest is used to apply a promotion request and
+// create an evaluation atomically.
+type ApplyDeploymentPromoteRequest struct {
+	DeploymentPromoteRequest
+
+	// Eval is used to create an evaluation at the same time as promoting the
+	// canaries of the deployment.
+	Eval *Evaluation
+}
+
 // AllocUpdateRequest is used to submit changes to allocations, either
 // to cause evictions or to assign new allocaitons. Both can be done
 // within a single transaction
@@ -703,6 +774,28 @@ type EvalAllocationsResponse struct {
 	QueryMeta
 }
 
+// DeploymentListResponse is used for a list request
+type DeploymentListResponse struct {
+	Deployments []*Deployment
+	QueryMeta
+}
+
+// SingleDeploymentResponse is used to return a single deployment
+type SingleDeploymentResponse struct {
+	Deployment *Deployment
+	QueryMeta
+}
+
+// DeploymentUpdateResponse is used to respond to a deployment change. The
+// response will include the modify index of the deployment as well as details
+// of any triggered evaluation.
+type DeploymentUpdateResponse struct {
+	EvalID                string
+	EvalCreateIndex       uint64
+	DeploymentModifyIndex uint64
+	WriteMeta
+}
+
 // PeriodicForceResponse is used to respond to a periodic job force launch
 type PeriodicForceResponse struct {
 	EvalID          string
@@ -3474,6 +3567,16 @@ const (
 	DeploymentStatusSuccessful = "successful"
 	DeploymentStatusCancelled  = "cancelled"
 	DeploymentStatusPaused     = "paused"
+
+	// DeploymentStatusDescriptions are the various descriptions of the states a
+	// deployment can be in.
+	DeploymentStatusDescriptionRunning           = "Deployment is running"
+	DeploymentStatusDescriptionPaused            = "Deployment is paused"
+	DeploymentStatusDescriptionSuccessful        = "Deployment completed successfully"
+	DeploymentStatusDescriptionFailedAllocations = "Failed due to unhealthy allocations"
+	DeploymentStatusDescriptionFailedByUser      = "Deployment marked as failed"
+	DeploymentStatusDescriptionStoppedJob        = "Cancelled because job is stopped"
+	DeploymentStatusDescriptionNewerJob          = "Cancelled due to newer version of job"
 )
 
 // Deployment is the object that represents a job deployment which is used to
@@ -3511,7 +3614,25 @@ type Deployment struct {
 	ModifyIndex uint64
 }
 
+// NewDeployment creates a new deployment given the job.
+func NewDeployment(job *Job) *Deployment {
+	return &Deployment{
+		ID:                GenerateUUID(),
+		JobID:             job.ID,
+		JobVersion:        job.Version,
+		JobModifyIndex:    job.ModifyIndex,
+		JobCreateIndex:    job.CreateIndex,
+		Status:            DeploymentStatusRunning,
+		StatusDescription: DeploymentStatusDescriptionRunning,
+		TaskGroups:        make(map[string]*DeploymentState, len(job.TaskGroups)),
+	}
+}
+
 func (d *Deployment) Copy() *Deployment {
+	if d == nil {
+		return nil
+	}
+
 	c := &Deployment{}
 	*c = *d
 
@@ -3536,6 +3657,45 @@ func (d *Deployment) Active() bool {
 	}
 }
 
+// Complete returns whether every task group tracked by the deployment has the
+// desired number of healthy allocations and has been promoted if required.
+func (d *Deployment) Complete() bool {
+	for _, state := range d.TaskGroups {
+		if state.RequiresPromotion && !state.Promoted {
+			return false
+		}
+		if state.HealthyAllocs < state.DesiredTotal {
+			return false
+		}
+	}
+	return true
+}
+
+// HasUnhealthyAllocs returns whether any task group tracked by the deployment
+// has an allocation that has been marked unhealthy.
+func (d *Deployment) HasUnhealthyAllocs() bool {
+	for _, state := range d.TaskGroups {
+		if state.UnhealthyAllocs != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// HealthyAllocs returns the number of healthy allocations across all task
+// groups tracked by the deployment.
+func (d *Deployment) HealthyAllocs() int {
+	healthy := 0
+	for _, state := range d.TaskGroups {
+		healthy += state.HealthyAllocs
+	}
+	return healthy
+}
+
+func (d *Deployment) GoString() string {
+	return fmt.Sprintf("<Deployment %q JobID: %q Status: %q>", d.ID, d.JobID, d.Status)
+}
+
 // DeploymentState tracks the state of a deployment for a given task group.
 type DeploymentState struct {
 	// Promoted marks whether the canaries have been. Promotion by
@@ -3771,6 +3931,7 @@ func (a *Allocation) Stub() *AllocListStub {
 		ClientStatus:       a.ClientStatus,
 		ClientDescription:  a.ClientDescription,
 		TaskStates:         a.TaskStates,
+		DeploymentStatus:   a.DeploymentStatus,
 		CreateIndex:        a.CreateIndex,
 		ModifyIndex:        a.ModifyIndex,
 		CreateTime:         a.CreateTime,
@@ -3834,6 +3995,7 @@ type AllocListStub struct {
 	ClientStatus       string
 	ClientDescription  string
 	TaskStates         map[string]*TaskState
+	DeploymentStatus   *AllocDeploymentStatus
 	CreateIndex        uint64
 	ModifyIndex        uint64
 	CreateTime         int64
@@ -3975,14 +4137,15 @@ const (
 )
 
 const (
-	EvalTriggerJobRegister    = "job-register"
-	EvalTriggerJobDeregister  = "job-deregister"
-	EvalTriggerPeriodicJob    = "periodic-job"
-	EvalTriggerNodeUpdate     = "node-update"
-	EvalTriggerScheduled      = "scheduled"
-	EvalTriggerRollingUpdate  = "rolling-update"
-	EvalTriggerFailedFollowUp = "failed-follow-up"
-	EvalTriggerMaxPlans       = "max-plan-attempts"
+	EvalTriggerJobRegister       = "job-register"
+	EvalTriggerJobDeregister     = "job-deregister"
+	EvalTriggerPeriodicJob       = "periodic-job"
+	EvalTriggerNodeUpdate        = "node-update"
+	EvalTriggerScheduled         = "scheduled"
+	EvalTriggerRollingUpdate     = "rolling-update"
+	EvalTriggerFailedFollowUp    = "failed-follow-up"
+	EvalTriggerMaxPlans          = "max-plan-attempts"
+	EvalTriggerDeploymentWatcher = "deployment-watcher"
 )
 
 const (
@@ -4345,7 +4508,8 @@ func (p *Plan) AppendAlloc(alloc *Allocation) {
 
 // IsNoOp checks if this plan would do nothing
 func (p *Plan) IsNoOp() bool {
-	return len(p.NodeUpdate) == 0 && len(p.NodeAllocation) == 0
+	return len(p.NodeUpdate) == 0 && len(p.NodeAllocation) == 0 &&
+		p.CreatedDeployment == nil && len(p.DeploymentUpdates) == 0
 }
 
 // PlanResult is the result of a plan submitted to the leader.
@@ -4365,11 +4529,18 @@ type PlanResult struct {
 	// AllocIndex is the Raft index in which the evictions and
 	// allocations took place. This is used for the write index.
 	AllocIndex uint64
+
+	// CreatedDeployment is the deployment that was committed.
+	CreatedDeployment *Deployment
+
+	// DeploymentUpdates is the set of deployment updates that were committed.
+	DeploymentUpdates []*DeploymentStatusUpdate
 }
 
 // IsNoOp checks if this plan result would do nothing
 func (p *PlanResult) IsNoOp() bool {
-	return len(p.NodeUpdate) == 0 && len(p.NodeAllocation) == 0
+	return len(p.NodeUpdate) == 0 && len(p.NodeAllocation) == 0 &&
+		p.CreatedDeployment == nil && len(p.DeploymentUpdates) == 0
 }
 
 // FullCommit is used to check if all the allocations in a plan
@@ -73,6 +73,10 @@ type GenericScheduler struct {
 	limitReached bool
 	nextEval     *structs.Evaluation
 
+	// deployment is the deployment tracking the current version of the job.
+	// It may be terminal if the deployment has already completed or failed.
+	deployment *structs.Deployment
+
 	blocked        *structs.Evaluation
 	failedTGAllocs map[string]*structs.AllocMetric
 	queuedAllocs   map[string]int
@@ -109,7 +113,8 @@ func (s *GenericScheduler) Process(eval *structs.Evaluation) error {
 	switch eval.TriggeredBy {
 	case structs.EvalTriggerJobRegister, structs.EvalTriggerNodeUpdate,
 		structs.EvalTriggerJobDeregister, structs.EvalTriggerRollingUpdate,
-		structs.EvalTriggerPeriodicJob, structs.EvalTriggerMaxPlans:
+		structs.EvalTriggerPeriodicJob, structs.EvalTriggerMaxPlans,
+		structs.EvalTriggerDeploymentWatcher:
 	default:
 		desc := fmt.Sprintf("scheduler cannot handle '%s' evaluation reason",
 			eval.TriggeredBy)
@@ -235,8 +240,10 @@ func (s *GenericScheduler) process() (bool, error) {
 	}
 
 	// If the limit of placements was reached we need to create an evaluation
-	// to pickup from here after the stagger period.
-	if s.limitReached && s.nextEval == nil {
+	// to pickup from here after the stagger period. Updates tracked by a
+	// deployment are instead continued by the deployment watcher once the
+	// placed allocations become healthy.
+	if s.limitReached && s.nextEval == nil && s.deployment == nil {
 		s.nextEval = s.eval.NextRollingEval(s.job.Update.Stagger)
 		if err := s.planner.CreateEval(s.nextEval); err != nil {
 			s.logger.Printf("[ERR] sched: %#v failed to make next eval for rolling update: %v", s.eval, err)
@@ -396,6 +403,11 @@ func (s *GenericScheduler) computeJobAllocs() error {
 		}
 	}
 
+	// Determine the deployment tracking the job's allocations
+	if err := s.computeDeployment(diff); err != nil {
+		return err
+	}
+
 	// Check if a rolling upgrade strategy is being used
 	limit := len(diff.update) + len(diff.migrate) + len(diff.lost)
 	if !s.job.Stopped() && s.job.Update.Rolling() {
@@ -405,8 +417,19 @@ func (s *GenericScheduler) computeJobAllocs() error {
 	// Treat migrations as an eviction and a new placement.
 	s.limitReached = evictAndPlace(s.ctx, diff, diff.migrate, allocMigrating, &limit)
 
+	// Destructive updates tracked by a deployment may only use the part of the
+	// limit that isn't taken by allocations that have yet to become healthy.
+	updateLimit, held := limit, 0
+	if s.deployment != nil {
+		if allowed := s.deploymentAllowance(allocs); allowed < updateLimit {
+			held = updateLimit - allowed
+			updateLimit = allowed
+		}
+	}
+
 	// Treat non in-place updates as an eviction and new placement.
-	s.limitReached = s.limitReached || evictAndPlace(s.ctx, diff, diff.update, allocUpdating, &limit)
+	s.limitReached = s.limitReached || evictAndPlace(s.ctx, diff, diff.update, allocUpdating, &updateLimit)
+	limit = updateLimit + held
 
 	// Lost allocations should be transistioned to desired status stop and client
 	// status lost and a new placement should be made
@@ -431,6 +454,109 @@ func (s *GenericScheduler) computeJobAllocs() error {
 	return s.computePlacements(diff.place)
 }
 
+// computeDeployment determines the deployment that tracks the current version
+// of the job. A deployment is created when a job with a rolling update strategy
+// has allocations to place or replace, and active deployments that no longer
+// track the job because it was stopped or updated are cancelled.
+func (s *GenericScheduler) computeDeployment(diff *diffResult) error {
+	s.deployment = nil
+
+	ws := memdb.NewWatchSet()
+	latest, err := s.state.LatestDeploymentByJobID(ws, s.eval.JobID)
+	if err != nil {
+		return fmt.Errorf("failed to get deployment for job '%s': %v", s.eval.JobID, err)
+	}
+
+	// Determine if the latest deployment is tracking the current job
+	if latest != nil && !s.job.Stopped() &&
+		latest.JobCreateIndex == s.job.CreateIndex &&
+		latest.JobVersion == s.job.Version {
+		s.deployment = latest
+		return nil
+	}
+
+	// Create a deployment if the job should be deployed. Creating the
+	// deployment cancels any prior active deployment.
+	if d := s.createDeployment(diff); d != nil {
+		s.deployment = d
+		s.plan.CreatedDeployment = d
+		return nil
+	}
+
+	// Cancel the latest deployment if it is still active
+	if latest != nil && latest.Active() {
+		desc := structs.DeploymentStatusDescriptionNewerJob
+		if s.job.Stopped() {
+			desc = structs.DeploymentStatusDescriptionStoppedJob
+		}
+		s.plan.DeploymentUpdates = append(s.plan.DeploymentUpdates, &structs.DeploymentStatusUpdate{
+			DeploymentID:      latest.ID,
+			Status:            structs.DeploymentStatusCancelled,
+			StatusDescription: desc,
+		})
+	}
+
+	return nil
+}
+
+// createDeployment returns a deployment for the current version of the job if
+// it is a service job with a rolling update strategy that has allocations to
+// place or replace. Otherwise nil is returned.
+func (s *GenericScheduler) createDeployment(diff *diffResult) *structs.Deployment {
+	if s.batch || s.job.Stopped() || !s.job.Update.Rolling() {
+		return nil
+	}
+
+	// Determine the task groups that have allocations to place or replace
+	changes := make(map[string]int)
+	for _, tuple := range diff.place {
+		changes[tuple.TaskGroup.Name]++
+	}
+	for _, tuple := range diff.update {
+		changes[tuple.TaskGroup.Name]++
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	d := structs.NewDeployment(s.job)
+	for tg, total := range changes {
+		d.TaskGroups[tg] = &structs.DeploymentState{
+			DesiredTotal: total,
+		}
+	}
+	return d
+}
+
+// deploymentAllowance returns the number of destructive updates the deployment
+// allows given the allocations that have been placed as part of it but have
+// yet to become healthy.
+func (s *GenericScheduler) deploymentAllowance(allocs []*structs.Allocation) int {
+	// A deployment that is paused or has finished does not allow any further
+	// destructive updates
+	d := s.deployment
+	if !d.Active() || d.Status == structs.DeploymentStatusPaused {
+		return 0
+	}
+
+	allowed := s.job.Update.MaxParallel
+	for _, alloc := range allocs {
+		if alloc.DeploymentID != d.ID {
+			continue
+		}
+
+		if alloc.DeploymentStatus == nil || alloc.DeploymentStatus.Healthy == nil ||
+			!*alloc.DeploymentStatus.Healthy {
+			allowed--
+		}
+	}
+
+	if allowed < 0 {
+		return 0
+	}
+	return allowed
+}
+
 // computePlacements computes placements for allocations
 func (s *GenericScheduler) computePlacements(place []allocTuple) error {
 	// Get the base nodes
@@ -492,6 +618,14 @@ func (s *GenericScheduler) computePlacements(place []allocTuple) error {
 				alloc.PreviousAllocation = missing.Alloc.ID
 			}
 
+			// If the task group is part of the active deployment, mark the
+			// allocation as being created by it
+			if d := s.deployment; d != nil && d.Active() {
+				if _, ok := d.TaskGroups[missing.TaskGroup.Name]; ok {
+					alloc.DeploymentID = d.ID
+				}
+			}
+
 			s.plan.AppendAlloc(alloc)
 		} else {
 			// Lazy initialize the failed map
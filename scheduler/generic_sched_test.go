@@ -8,6 +8,7 @@ import (
 	"time"
 
 	memdb "github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/nomad/helper"
 	"github.com/hashicorp/nomad/nomad/mock"
 	"github.com/hashicorp/nomad/nomad/structs"
 )
@@ -1443,27 +1444,247 @@ func TestServiceSched_JobModify_Rolling(t *testing.T) {
 
 	h.AssertEvalStatus(t, structs.EvalStatusComplete)
 
-	// Ensure a follow up eval was created
-	eval = h.Evals[0]
-	if eval.NextEval == "" {
-		t.Fatalf("missing next eval")
+	// Ensure a deployment was created to track the update
+	d := plan.CreatedDeployment
+	if d == nil {
+		t.Fatalf("missing deployment")
+	}
+	if d.JobVersion != job2.Version || d.Status != structs.DeploymentStatusRunning {
+		t.Fatalf("bad: %#v", d)
+	}
+	state, ok := d.TaskGroups["web"]
+	if !ok || state.DesiredTotal != 10 {
+		t.Fatalf("bad: %#v", d.TaskGroups)
 	}
 
-	// Check for create
-	if len(h.CreateEvals) == 0 {
-		t.Fatalf("missing created eval")
+	// Ensure the placed allocations are part of the deployment
+	for _, alloc := range planned {
+		if alloc.DeploymentID != d.ID {
+			t.Fatalf("bad: %#v", alloc)
+		}
 	}
-	create := h.CreateEvals[0]
-	if eval.NextEval != create.ID {
-		t.Fatalf("ID mismatch")
+
+	// Ensure no follow up eval was created since the deployment watcher
+	// continues the update as allocations become healthy
+	if eval := h.Evals[0]; eval.NextEval != "" || len(h.CreateEvals) != 0 {
+		t.Fatalf("bad: %#v %#v", eval, h.CreateEvals)
 	}
-	if create.PreviousEval != eval.ID {
-		t.Fatalf("missing previous eval")
+}
+
+func TestServiceSched_JobModify_Rolling_Deployment(t *testing.T) {
+	h := NewHarness(t)
+
+	// Create some nodes
+	var nodes []*structs.Node
+	for i := 0; i < 10; i++ {
+		node := mock.Node()
+		nodes = append(nodes, node)
+		noErr(t, h.State.UpsertNode(h.NextIndex(), node))
 	}
 
-	if create.TriggeredBy != structs.EvalTriggerRollingUpdate {
-		t.Fatalf("bad: %#v", create)
+	// Generate a fake job with allocations and an update policy
+	job := mock.Job()
+	job.Update = structs.UpdateStrategy{
+		Stagger:     30 * time.Second,
+		MaxParallel: 5,
 	}
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	// Update the job and create a deployment tracking the new version
+	job2 := mock.Job()
+	job2.ID = job.ID
+	job2.Update = job.Update
+	job2.TaskGroups[0].Tasks[0].Config["command"] = "/bin/other"
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job2))
+
+	d := structs.NewDeployment(job2)
+	d.TaskGroups["web"] = &structs.DeploymentState{DesiredTotal: 10}
+	noErr(t, h.State.UpsertDeployment(h.NextIndex(), d, false))
+
+	// Create five allocations from the old version of the job and five from
+	// the deployment, three of which are healthy
+	var allocs []*structs.Allocation
+	for i := 0; i < 10; i++ {
+		alloc := mock.Alloc()
+		alloc.Job = job
+		alloc.JobID = job.ID
+		alloc.NodeID = nodes[i].ID
+		alloc.Name = fmt.Sprintf("my-job.web[%d]", i)
+		if i >= 5 {
+			alloc.Job = job2
+			alloc.DeploymentID = d.ID
+			if i < 8 {
+				alloc.DeploymentStatus = &structs.AllocDeploymentStatus{
+					Healthy: helper.BoolToPtr(true),
+				}
+			}
+		}
+		allocs = append(allocs, alloc)
+	}
+	noErr(t, h.State.UpsertAllocs(h.NextIndex(), allocs))
+
+	// Create a mock evaluation triggered by the deployment watcher
+	eval := &structs.Evaluation{
+		ID:          structs.GenerateUUID(),
+		Priority:    50,
+		TriggeredBy: structs.EvalTriggerDeploymentWatcher,
+		JobID:       job.ID,
+	}
+
+	// Process the evaluation
+	err := h.Process(NewServiceScheduler, eval)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure a single plan
+	if len(h.Plans) != 1 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+	plan := h.Plans[0]
+
+	// Ensure the plan didn't create a new deployment
+	if plan.CreatedDeployment != nil || len(plan.DeploymentUpdates) != 0 {
+		t.Fatalf("bad: %#v", plan)
+	}
+
+	// Ensure the plan only replaced as many allocations as are allowed by the
+	// two unhealthy allocations
+	var update []*structs.Allocation
+	for _, updateList := range plan.NodeUpdate {
+		update = append(update, updateList...)
+	}
+	if len(update) != 3 {
+		t.Fatalf("bad: %#v", plan)
+	}
+
+	var planned []*structs.Allocation
+	for _, allocList := range plan.NodeAllocation {
+		planned = append(planned, allocList...)
+	}
+	if len(planned) != 3 {
+		t.Fatalf("bad: %#v", plan)
+	}
+	for _, alloc := range planned {
+		if alloc.DeploymentID != d.ID {
+			t.Fatalf("bad: %#v", alloc)
+		}
+	}
+
+	h.AssertEvalStatus(t, structs.EvalStatusComplete)
+}
+
+func TestServiceSched_JobModify_Rolling_PausedDeployment(t *testing.T) {
+	h := NewHarness(t)
+
+	// Create some nodes
+	var nodes []*structs.Node
+	for i := 0; i < 10; i++ {
+		node := mock.Node()
+		nodes = append(nodes, node)
+		noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+	}
+
+	// Generate a fake job with an update policy
+	job := mock.Job()
+	job.Update = structs.UpdateStrategy{
+		Stagger:     30 * time.Second,
+		MaxParallel: 5,
+	}
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	// Create allocations from the current version of the job
+	var allocs []*structs.Allocation
+	for i := 0; i < 10; i++ {
+		alloc := mock.Alloc()
+		alloc.Job = job
+		alloc.JobID = job.ID
+		alloc.NodeID = nodes[i].ID
+		alloc.Name = fmt.Sprintf("my-job.web[%d]", i)
+		allocs = append(allocs, alloc)
+	}
+	noErr(t, h.State.UpsertAllocs(h.NextIndex(), allocs))
+
+	// Update the job and create a paused deployment tracking the new version
+	job2 := mock.Job()
+	job2.ID = job.ID
+	job2.Update = job.Update
+	job2.TaskGroups[0].Tasks[0].Config["command"] = "/bin/other"
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job2))
+
+	d := structs.NewDeployment(job2)
+	d.Status = structs.DeploymentStatusPaused
+	d.TaskGroups["web"] = &structs.DeploymentState{DesiredTotal: 10}
+	noErr(t, h.State.UpsertDeployment(h.NextIndex(), d, false))
+
+	// Create a mock evaluation
+	eval := &structs.Evaluation{
+		ID:          structs.GenerateUUID(),
+		Priority:    50,
+		TriggeredBy: structs.EvalTriggerJobRegister,
+		JobID:       job.ID,
+	}
+
+	// Process the evaluation
+	err := h.Process(NewServiceScheduler, eval)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure no plan as the deployment is paused
+	if len(h.Plans) != 0 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+
+	h.AssertEvalStatus(t, structs.EvalStatusComplete)
+}
+
+func TestServiceSched_JobDeregister_CancelDeployment(t *testing.T) {
+	h := NewHarness(t)
+
+	// Generate a stopped job with an active deployment
+	job := mock.Job()
+	job.Stop = true
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	d := structs.NewDeployment(job)
+	d.TaskGroups["web"] = &structs.DeploymentState{DesiredTotal: 10}
+	noErr(t, h.State.UpsertDeployment(h.NextIndex(), d, false))
+
+	// Create a mock evaluation to deregister the job
+	eval := &structs.Evaluation{
+		ID:          structs.GenerateUUID(),
+		Priority:    50,
+		TriggeredBy: structs.EvalTriggerJobDeregister,
+		JobID:       job.ID,
+	}
+
+	// Process the evaluation
+	err := h.Process(NewServiceScheduler, eval)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure a single plan cancelling the deployment
+	if len(h.Plans) != 1 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+	updates := h.Plans[0].DeploymentUpdates
+	if len(updates) != 1 || updates[0].DeploymentID != d.ID ||
+		updates[0].Status != structs.DeploymentStatusCancelled ||
+		updates[0].StatusDescription != structs.DeploymentStatusDescriptionStoppedJob {
+		t.Fatalf("bad: %#v", updates)
+	}
+
+	// Ensure the deployment was cancelled
+	ws := memdb.NewWatchSet()
+	out, err := h.State.DeploymentByID(ws, d.ID)
+	noErr(t, err)
+	if out.Status != structs.DeploymentStatusCancelled {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	h.AssertEvalStatus(t, structs.EvalStatusComplete)
 }
 
 func TestServiceSched_JobModify_InPlace(t *testing.T) {
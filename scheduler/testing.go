@@ -98,6 +98,8 @@ func (h *Harness) SubmitPlan(plan *structs.Plan) (*structs.PlanResult, State, er
 	result.NodeUpdate = plan.NodeUpdate
 	result.NodeAllocation = plan.NodeAllocation
 	result.AllocIndex = index
+	result.CreatedDeployment = plan.CreatedDeployment
+	result.DeploymentUpdates = plan.DeploymentUpdates
 
 	// Flatten evicts and allocs
 	var allocs []*structs.Allocation
@@ -120,7 +122,15 @@ func (h *Harness) SubmitPlan(plan *structs.Plan) (*structs.PlanResult, State, er
 	}
 
 	// Apply the full plan
-	err := h.State.UpsertAllocs(index, allocs)
+	req := structs.ApplyPlanResultsRequest{
+		AllocUpdateRequest: structs.AllocUpdateRequest{
+			Job:   plan.Job,
+			Alloc: allocs,
+		},
+		CreatedDeployment: plan.CreatedDeployment,
+		DeploymentUpdates: plan.DeploymentUpdates,
+	}
+	err := h.State.UpsertPlanResults(index, &req)
 	return result, nil, err
 }
 